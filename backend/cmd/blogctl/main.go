@@ -0,0 +1,288 @@
+// Command blogctl imports and exports posts as Markdown files with YAML front
+// matter through the ModernBlog API.
+//
+// Usage:
+//
+//	blogctl import [-dry-run] PATH...
+//	blogctl export [-post ID] [-status STATUS] [-o FILE]
+//
+// PATH may be a .md file, a .zip archive or a directory of .md files. The API
+// address and access token are read from BLOG_API_URL and BLOG_TOKEN, or from
+// the -api and -token flags.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+type client struct {
+	baseURL string
+	token   string
+	http    *http.Client
+}
+
+type importResult struct {
+	File    string   `json:"file"`
+	Slug    string   `json:"slug"`
+	Action  string   `json:"action"`
+	Changes []string `json:"changes"`
+	Error   string   `json:"error"`
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "import":
+		err = runImport(os.Args[2:])
+	case "export":
+		err = runExport(os.Args[2:])
+	default:
+		usage()
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "blogctl:", err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: blogctl import [-dry-run] PATH...")
+	fmt.Fprintln(os.Stderr, "       blogctl export [-post ID] [-status STATUS] [-o FILE]")
+	os.Exit(2)
+}
+
+func newFlagSet(name string) (*flag.FlagSet, *string, *string) {
+	flags := flag.NewFlagSet(name, flag.ExitOnError)
+	apiURL := flags.String("api", getEnv("BLOG_API_URL", "http://localhost:8080/api/v1"), "API base URL")
+	token := flags.String("token", os.Getenv("BLOG_TOKEN"), "access token")
+	return flags, apiURL, token
+}
+
+func runImport(args []string) error {
+	flags, apiURL, token := newFlagSet("import")
+	dryRun := flags.Bool("dry-run", false, "report changes without applying them")
+	flags.Parse(args)
+
+	if flags.NArg() == 0 {
+		return fmt.Errorf("no files to import")
+	}
+
+	var files []string
+	for _, arg := range flags.Args() {
+		found, err := collectFiles(arg)
+		if err != nil {
+			return err
+		}
+		files = append(files, found...)
+	}
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		part, err := form.CreateFormFile("file", filepath.ToSlash(file))
+		if err != nil {
+			return err
+		}
+		if _, err := part.Write(data); err != nil {
+			return err
+		}
+	}
+	if err := form.Close(); err != nil {
+		return err
+	}
+
+	query := url.Values{}
+	if *dryRun {
+		query.Set("dry_run", "true")
+	}
+
+	c := newClient(*apiURL, *token)
+	resp, err := c.do(http.MethodPost, "/posts/import?"+query.Encode(), form.FormDataContentType(), &body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var out struct {
+		Results []importResult `json:"results"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "FILE\tSLUG\tACTION\tDETAILS")
+	failed := false
+	for _, r := range out.Results {
+		details := strings.Join(r.Changes, ", ")
+		if r.Error != "" {
+			details = r.Error
+			failed = true
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", r.File, r.Slug, r.Action, details)
+	}
+	w.Flush()
+
+	if *dryRun {
+		fmt.Println("dry run: no changes were applied")
+	}
+	if failed {
+		return fmt.Errorf("some files could not be imported")
+	}
+	return nil
+}
+
+func runExport(args []string) error {
+	flags, apiURL, token := newFlagSet("export")
+	postID := flags.String("post", "", "export a single post by ID")
+	status := flags.String("status", "", "only export posts with this status")
+	output := flags.String("o", "", "output file (default posts.zip, or the post's slug)")
+	flags.Parse(args)
+
+	endpoint := "/posts/export"
+	if *postID != "" {
+		endpoint = "/posts/" + url.PathEscape(*postID) + "/export"
+	} else if *status != "" {
+		endpoint += "?status=" + url.QueryEscape(*status)
+	}
+
+	c := newClient(*apiURL, *token)
+	resp, err := c.do(http.MethodGet, endpoint, "", nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	name := *output
+	if name == "" {
+		name = "posts.zip"
+		if *postID != "" {
+			name = *postID + ".md"
+			if filename := attachmentName(resp.Header.Get("Content-Disposition")); filename != "" {
+				name = filename
+			}
+		}
+	}
+
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, resp.Body); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	fmt.Println("wrote", name)
+	return nil
+}
+
+// collectFiles expands a directory into the Markdown files below it.
+func collectFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	var files []string
+	err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && p != path && strings.HasPrefix(d.Name(), ".") {
+			return filepath.SkipDir
+		}
+		ext := strings.ToLower(filepath.Ext(p))
+		if !d.IsDir() && (ext == ".md" || ext == ".markdown") {
+			files = append(files, p)
+		}
+		return nil
+	})
+	return files, err
+}
+
+func newClient(baseURL, token string) *client {
+	return &client{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		token:   token,
+		http:    &http.Client{Timeout: 5 * time.Minute},
+	}
+}
+
+func (c *client) do(method, endpoint, contentType string, body io.Reader) (*http.Response, error) {
+	if c.token == "" {
+		return nil, fmt.Errorf("no access token, set BLOG_TOKEN or -token")
+	}
+
+	req, err := http.NewRequest(method, c.baseURL+endpoint, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		var apiErr struct {
+			Error string `json:"error"`
+		}
+		json.NewDecoder(resp.Body).Decode(&apiErr)
+		if apiErr.Error == "" {
+			apiErr.Error = resp.Status
+		}
+		return nil, fmt.Errorf("%s %s: %s", method, endpoint, apiErr.Error)
+	}
+	return resp, nil
+}
+
+// attachmentName returns the file name suggested by a Content-Disposition
+// header, stripped of any directory.
+func attachmentName(header string) string {
+	_, params, err := mime.ParseMediaType(header)
+	if err != nil || params["filename"] == "" {
+		return ""
+	}
+	return filepath.Base(params["filename"])
+}
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/yairfalse/modern-cloud-app/backend/internal/api/routes"
 	"github.com/yairfalse/modern-cloud-app/backend/internal/config"
 	"github.com/yairfalse/modern-cloud-app/backend/internal/database"
+	"github.com/yairfalse/modern-cloud-app/backend/internal/jobs"
 	"github.com/yairfalse/modern-cloud-app/backend/pkg/logger"
 )
 
@@ -50,7 +51,8 @@ func main() {
 		corsConfig.AllowOrigins = []string{"http://localhost:5173", "http://localhost:3000"}
 	}
 	corsConfig.AllowMethods = []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}
-	corsConfig.AllowHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization"}
+	corsConfig.AllowHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization", "If-Match", "X-Unlock-Token"}
+	corsConfig.ExposeHeaders = []string{"ETag", "Location"}
 	corsConfig.AllowCredentials = true
 
 	r.Use(cors.New(corsConfig))
@@ -59,8 +61,10 @@ func main() {
 	r.GET("/", handleRoot)
 	r.GET("/health", handleHealth)
 
-	// API routes
-	routes.Setup(r, db, cfg)
+	// API routes and their background workers
+	runner := jobs.NewRunner()
+	routes.Setup(r, db, cfg, runner)
+	runner.Start(context.Background())
 
 	// Create server
 	srv := &http.Server{
@@ -103,6 +107,12 @@ func main() {
 		log.Error("Server forced to shutdown:", err)
 	}
 
+	// Stop background workers after requests have drained; this also
+	// flushes buffered view counts
+	if err := runner.Stop(ctx); err != nil {
+		log.Error("Background workers did not stop in time:", err)
+	}
+
 	log.Info("Server exited")
 }
 
@@ -0,0 +1,75 @@
+// Command wpimport imports a WordPress WXR export into the database.
+//
+// Usage:
+//
+//	wpimport [-dry-run] [-update] [-source URL] FILE
+//
+// Authors become users without a usable password, and guest commenters
+// become inactive users. Re-running the same export is safe: items already
+// imported are skipped, or refreshed with -update.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/yairfalse/modern-cloud-app/backend/internal/config"
+	"github.com/yairfalse/modern-cloud-app/backend/internal/database"
+	"github.com/yairfalse/modern-cloud-app/backend/internal/duplicates"
+	"github.com/yairfalse/modern-cloud-app/backend/internal/linkgraph"
+	"github.com/yairfalse/modern-cloud-app/backend/internal/wordpress"
+)
+
+func main() {
+	dryRun := flag.Bool("dry-run", false, "run the import and roll it back")
+	update := flag.Bool("update", false, "overwrite posts imported by an earlier run")
+	source := flag.String("source", "", "site URL to key imported items by (default: from the export)")
+	locale := flag.String("locale", "", "locale of the imported posts (default: DEFAULT_LOCALE)")
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: wpimport [-dry-run] [-update] [-source URL] [-locale LOCALE] FILE")
+		os.Exit(2)
+	}
+
+	f, err := os.Open(flag.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+
+	cfg := config.Load()
+	if *locale == "" {
+		*locale = cfg.I18n.DefaultLocale
+	}
+	if !cfg.I18n.Supported(*locale) {
+		log.Fatalf("Unsupported locale %q, configure it in LOCALES", *locale)
+	}
+
+	db, err := database.Connect(cfg.Database)
+	if err != nil {
+		log.Fatal("Failed to connect to database: ", err)
+	}
+	if err := database.Migrate(db); err != nil {
+		log.Fatal("Failed to run migrations: ", err)
+	}
+
+	stats, err := wordpress.NewImporter(db, linkgraph.NewGraph(cfg.Links), duplicates.NewDetector(cfg.Duplicates)).Import(f, wordpress.Options{
+		DryRun: *dryRun,
+		Update: *update,
+		Source: *source,
+		Locale: *locale,
+	})
+	if err != nil {
+		log.Fatal("Import failed: ", err)
+	}
+
+	out, _ := json.MarshalIndent(stats, "", "  ")
+	fmt.Println(string(out))
+	if *dryRun {
+		fmt.Println("dry run: no changes were saved")
+	}
+}
@@ -0,0 +1,132 @@
+package analytics
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/yairfalse/modern-cloud-app/backend/internal/config"
+	"github.com/yairfalse/modern-cloud-app/backend/internal/database/models"
+)
+
+const defaultRollupInterval = 15 * time.Minute
+
+// Rollup folds raw post events into daily aggregates and enforces the
+// configured retention for both.
+type Rollup struct {
+	db  *gorm.DB
+	cfg config.AnalyticsConfig
+}
+
+func NewRollup(db *gorm.DB, cfg config.AnalyticsConfig) *Rollup {
+	if cfg.RollupInterval <= 0 {
+		cfg.RollupInterval = defaultRollupInterval
+	}
+	return &Rollup{db: db, cfg: cfg}
+}
+
+func (r *Rollup) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.cfg.RollupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := r.RollUp(); err != nil {
+				log.Printf("analytics: rollup failed: %v", err)
+			}
+			if err := r.Prune(); err != nil {
+				log.Printf("analytics: prune failed: %v", err)
+			}
+		}
+	}
+}
+
+// RollUp recomputes the aggregates of every post and day that received new
+// events. Events are claimed first, so events arriving during the rollup are
+// picked up by the next run rather than lost.
+func (r *Rollup) RollUp() error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var touched []struct {
+			PostID uuid.UUID
+			Day    time.Time
+		}
+		if err := tx.Raw(`
+			UPDATE post_events SET rolled_up = true
+			WHERE rolled_up = false
+			RETURNING post_id, day`).Scan(&touched).Error; err != nil {
+			return err
+		}
+
+		byDay := make(map[time.Time][]uuid.UUID)
+		seen := make(map[uuid.UUID]map[time.Time]bool)
+		for _, t := range touched {
+			if seen[t.PostID] == nil {
+				seen[t.PostID] = make(map[time.Time]bool)
+			}
+			if seen[t.PostID][t.Day] {
+				continue
+			}
+			seen[t.PostID][t.Day] = true
+			byDay[t.Day] = append(byDay[t.Day], t.PostID)
+		}
+
+		for day, postIDs := range byDay {
+			if err := tx.Exec(`
+				INSERT INTO post_daily_stats (post_id, day, views, unique_viewers, likes, comments)
+				SELECT post_id, day,
+					COUNT(*) FILTER (WHERE type = ?),
+					COUNT(DISTINCT viewer_hash) FILTER (WHERE type = ?),
+					COUNT(*) FILTER (WHERE type = ?),
+					COUNT(*) FILTER (WHERE type = ?)
+				FROM post_events
+				WHERE day = ? AND post_id IN ?
+				GROUP BY post_id, day
+				ON CONFLICT (post_id, day) DO UPDATE SET
+					views = EXCLUDED.views,
+					unique_viewers = EXCLUDED.unique_viewers,
+					likes = EXCLUDED.likes,
+					comments = EXCLUDED.comments`,
+				models.PostEventView, models.PostEventView, models.PostEventLike, models.PostEventComment,
+				day, postIDs,
+			).Error; err != nil {
+				return err
+			}
+
+			if err := tx.Exec(`
+				INSERT INTO post_referrer_stats (post_id, day, domain, views)
+				SELECT post_id, day, referrer, COUNT(*)
+				FROM post_events
+				WHERE day = ? AND post_id IN ? AND type = ? AND referrer <> ''
+				GROUP BY post_id, day, referrer
+				ON CONFLICT (post_id, day, domain) DO UPDATE SET views = EXCLUDED.views`,
+				day, postIDs, models.PostEventView,
+			).Error; err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// Prune deletes rolled-up raw events and aggregates past their retention.
+func (r *Rollup) Prune() error {
+	now := time.Now().UTC()
+
+	if err := r.db.Where("rolled_up = ? AND occurred_at < ?", true, now.Add(-r.cfg.RawRetention)).
+		Delete(&models.PostEvent{}).Error; err != nil {
+		return err
+	}
+
+	statsCutoff := now.Add(-r.cfg.StatsRetention).Truncate(24 * time.Hour)
+	if err := r.db.Where("day < ?", statsCutoff).Delete(&models.PostDailyStat{}).Error; err != nil {
+		return err
+	}
+	return r.db.Where("day < ?", statsCutoff).Delete(&models.PostReferrerStat{}).Error
+}
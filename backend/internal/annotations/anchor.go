@@ -0,0 +1,151 @@
+// Package annotations keeps editorial annotations attached to the right text
+// as a post's content is edited.
+package annotations
+
+import (
+	"errors"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/yairfalse/modern-cloud-app/backend/internal/database/models"
+)
+
+// contextLength is how much text around the quote is kept to tell repeated
+// occurrences apart.
+const contextLength = 32
+
+var ErrInvalidRange = errors.New("annotation range is outside the content")
+
+// Anchor is a range of content counted in Unicode code points, along with the
+// text it covers and some context on either side.
+type Anchor struct {
+	Start  int
+	End    int
+	Quote  string
+	Prefix string
+	Suffix string
+}
+
+// NewAnchor anchors the range [start, end) of content.
+func NewAnchor(content string, start, end int) (Anchor, error) {
+	runes := []rune(content)
+	if start < 0 || end <= start || end > len(runes) {
+		return Anchor{}, ErrInvalidRange
+	}
+	return anchorAt(runes, start, end), nil
+}
+
+// Relocate finds the anchor in changed content. The range is kept when it
+// still covers the quote; otherwise the occurrence of the quote whose
+// surroundings match best wins, and ties go to the one closest to the old
+// position. It reports false when the quote no longer appears at all.
+func (a Anchor) Relocate(content string) (Anchor, bool) {
+	runes := []rune(content)
+	quote := []rune(a.Quote)
+	if len(quote) == 0 {
+		return a, false
+	}
+
+	if a.End <= len(runes) && a.Start >= 0 && string(runes[a.Start:a.End]) == a.Quote {
+		return anchorAt(runes, a.Start, a.End), true
+	}
+
+	prefix, suffix := []rune(a.Prefix), []rune(a.Suffix)
+	best, bestScore, bestDistance := -1, -1, 0
+	for i := 0; i+len(quote) <= len(runes); i++ {
+		if !hasPrefixAt(runes, quote, i) {
+			continue
+		}
+		score := commonSuffix(runes[:i], prefix) + commonPrefix(runes[i+len(quote):], suffix)
+		distance := abs(i - a.Start)
+		if score > bestScore || (score == bestScore && distance < bestDistance) {
+			best, bestScore, bestDistance = i, score, distance
+		}
+	}
+	if best < 0 {
+		return a, false
+	}
+	return anchorAt(runes, best, best+len(quote)), true
+}
+
+// Reanchor moves the anchors of a post's annotations to its new content and
+// marks the ones whose text is gone as orphaned. version is the post version
+// the content belongs to.
+func Reanchor(tx *gorm.DB, postID uuid.UUID, content string, version int) error {
+	var anchored []models.Annotation
+	if err := tx.Where("post_id = ? AND parent_id IS NULL", postID).Find(&anchored).Error; err != nil {
+		return err
+	}
+
+	for _, annotation := range anchored {
+		current := Anchor{
+			Start:  annotation.Start,
+			End:    annotation.End,
+			Quote:  annotation.Quote,
+			Prefix: annotation.Prefix,
+			Suffix: annotation.Suffix,
+		}
+		moved, ok := current.Relocate(content)
+
+		updates := map[string]interface{}{
+			"post_version": version,
+			"orphaned":     !ok,
+		}
+		if ok {
+			updates["start_offset"] = moved.Start
+			updates["end_offset"] = moved.End
+			updates["prefix"] = moved.Prefix
+			updates["suffix"] = moved.Suffix
+		}
+		if err := tx.Model(&models.Annotation{}).Where("id = ?", annotation.ID).
+			UpdateColumns(updates).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func anchorAt(runes []rune, start, end int) Anchor {
+	return Anchor{
+		Start:  start,
+		End:    end,
+		Quote:  string(runes[start:end]),
+		Prefix: string(runes[max(0, start-contextLength):start]),
+		Suffix: string(runes[end:min(len(runes), end+contextLength)]),
+	}
+}
+
+func hasPrefixAt(runes, quote []rune, at int) bool {
+	for j, r := range quote {
+		if runes[at+j] != r {
+			return false
+		}
+	}
+	return true
+}
+
+// commonSuffix counts how many trailing runes of a and b agree.
+func commonSuffix(a, b []rune) int {
+	n := 0
+	for n < len(a) && n < len(b) && a[len(a)-1-n] == b[len(b)-1-n] {
+		n++
+	}
+	return n
+}
+
+// commonPrefix counts how many leading runes of a and b agree.
+func commonPrefix(a, b []rune) int {
+	n := 0
+	for n < len(a) && n < len(b) && a[n] == b[n] {
+		n++
+	}
+	return n
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
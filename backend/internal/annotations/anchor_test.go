@@ -0,0 +1,170 @@
+package annotations
+
+import (
+	"strings"
+	"testing"
+)
+
+// runeIndex returns the code point offset of the n-th (0-based) occurrence
+// of sub in s.
+func runeIndex(t *testing.T, s, sub string, n int) int {
+	t.Helper()
+	offset := 0
+	for i := 0; ; i++ {
+		at := strings.Index(s[offset:], sub)
+		if at < 0 {
+			t.Fatalf("%q occurs fewer than %d times in %q", sub, n+1, s)
+		}
+		if i == n {
+			return len([]rune(s[:offset+at]))
+		}
+		offset += at + len(sub)
+	}
+}
+
+func TestNewAnchor(t *testing.T) {
+	content := "שלום, world"
+
+	a, err := NewAnchor(content, 0, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Quote != "שלום" || a.Prefix != "" || a.Suffix != ", world" {
+		t.Errorf("anchor = %+v", a)
+	}
+
+	for _, r := range [][2]int{{-1, 2}, {3, 3}, {4, 2}, {0, 12}} {
+		if _, err := NewAnchor(content, r[0], r[1]); err != ErrInvalidRange {
+			t.Errorf("NewAnchor(%d, %d) error = %v, want ErrInvalidRange", r[0], r[1], err)
+		}
+	}
+}
+
+func TestRelocate(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		quote   string
+		nth     int // which occurrence of quote in content is anchored
+		changed string
+		want    int // occurrence of quote in changed, or -1 when orphaned
+	}{
+		{
+			name:    "unchanged",
+			content: "The quick brown fox jumps over the lazy dog.",
+			quote:   "brown fox",
+			changed: "The quick brown fox jumps over the lazy dog.",
+		},
+		{
+			name:    "text inserted before",
+			content: "The quick brown fox jumps over the lazy dog.",
+			quote:   "brown fox",
+			changed: "Intro paragraph.\n\nThe quick brown fox jumps over the lazy dog.",
+		},
+		{
+			name:    "text inserted after",
+			content: "The quick brown fox jumps.",
+			quote:   "brown fox",
+			changed: "The quick brown fox jumps. It lands.",
+		},
+		{
+			name:    "range still covers a repeated quote",
+			content: "a cat here and a cat there",
+			quote:   "cat",
+			nth:     1,
+			changed: "a cat here and a cat there, and a cat everywhere",
+			want:    1,
+		},
+		{
+			name:    "repeated quote picked by context",
+			content: "First the cat sat on the mat. Then the cat ran off.",
+			quote:   "cat",
+			nth:     1,
+			changed: "Once upon a time. First the cat sat on the mat. Then the cat ran off.",
+			want:    1,
+		},
+		{
+			name:    "repeated quote in reordered sentences",
+			content: "xx cat one. yy cat two.",
+			quote:   "cat",
+			nth:     1,
+			changed: "yy cat two. and xx cat one.",
+			want:    0,
+		},
+		{
+			name:    "edited context still finds the closest match",
+			content: "Alpha beta gamma delta.",
+			quote:   "gamma",
+			changed: "Alpha BETA gamma DELTA and more.",
+		},
+		{
+			name:    "deleted quote",
+			content: "Keep this. Remove that sentence. Keep the rest.",
+			quote:   "Remove that sentence.",
+			changed: "Keep this. Keep the rest.",
+			want:    -1,
+		},
+		{
+			name:    "quote edited",
+			content: "The colour is red.",
+			quote:   "colour",
+			changed: "The color is red.",
+			want:    -1,
+		},
+		{
+			name:    "multibyte text inserted before",
+			content: "שלום עולם, hello world",
+			quote:   "hello",
+			changed: "🎉 כותרת חדשה\n\nשלום עולם, hello world",
+		},
+		{
+			name:    "multibyte quote",
+			content: "Intro. שלום עולם. Outro.",
+			quote:   "עולם",
+			changed: "Intro — 🙂 — שלום עולם. Outro.",
+		},
+		{
+			name:    "multibyte repeated quote",
+			content: "ב׳ אחד: מים. ג׳ שניים: מים.",
+			quote:   "מים",
+			nth:     1,
+			changed: "א׳ הקדמה. ב׳ אחד: מים. ג׳ שניים: מים.",
+			want:    1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := runeIndex(t, tt.content, tt.quote, tt.nth)
+			a, err := NewAnchor(tt.content, start, start+len([]rune(tt.quote)))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			moved, ok := a.Relocate(tt.changed)
+			if tt.want < 0 {
+				if ok {
+					t.Fatalf("Relocate found %+v, want orphaned", moved)
+				}
+				return
+			}
+			if !ok {
+				t.Fatal("Relocate reported the quote as gone")
+			}
+
+			wantStart := runeIndex(t, tt.changed, tt.quote, tt.want)
+			if moved.Start != wantStart || moved.End != wantStart+len([]rune(tt.quote)) {
+				t.Errorf("range = [%d, %d), want [%d, %d)", moved.Start, moved.End, wantStart, wantStart+len([]rune(tt.quote)))
+			}
+			if got := string([]rune(tt.changed)[moved.Start:moved.End]); got != tt.quote {
+				t.Errorf("range covers %q, want %q", got, tt.quote)
+			}
+		})
+	}
+}
+
+func TestRelocateEmptyQuote(t *testing.T) {
+	if _, ok := (Anchor{}).Relocate("anything"); ok {
+		t.Error("an empty quote was relocated")
+	}
+}
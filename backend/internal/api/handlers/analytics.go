@@ -0,0 +1,349 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/yairfalse/modern-cloud-app/backend/internal/api/middleware"
+	"github.com/yairfalse/modern-cloud-app/backend/internal/database/models"
+)
+
+const (
+	statsDateLayout   = "2006-01-02"
+	statsDefaultRange = 30
+	statsMaxRange     = 366
+)
+
+type AnalyticsHandler struct {
+	db *gorm.DB
+}
+
+type StatsQuery struct {
+	From        string `form:"from"`
+	To          string `form:"to"`
+	Granularity string `form:"granularity,default=day"`
+}
+
+// StatsPoint holds the engagement for one day or week. For weekly points,
+// unique viewers is the sum of daily unique viewers.
+type StatsPoint struct {
+	Period        time.Time `json:"period"`
+	Views         int64     `json:"views"`
+	UniqueViewers int64     `json:"unique_viewers"`
+	Likes         int64     `json:"likes"`
+	Comments      int64     `json:"comments"`
+}
+
+type ReferrerCount struct {
+	Domain string `json:"domain"`
+	Views  int64  `json:"views"`
+}
+
+type statsRange struct {
+	from        time.Time
+	to          time.Time
+	granularity string
+}
+
+func NewAnalyticsHandler(db *gorm.DB) *AnalyticsHandler {
+	return &AnalyticsHandler{db: db}
+}
+
+func (h *AnalyticsHandler) GetPostStats(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	postUUID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid post ID",
+		})
+		return
+	}
+
+	r, ok := parseStatsRange(c)
+	if !ok {
+		return
+	}
+
+	var post models.Post
+	if err := h.db.Scopes(canEditPost(userID)).Where("posts.id = ?", postUUID).First(&post).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Post not found or not authorized",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch post",
+			})
+		}
+		return
+	}
+
+	postIDs := []uuid.UUID{post.ID}
+
+	series, err := h.series(postIDs, r)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch stats",
+		})
+		return
+	}
+
+	referrers, err := h.topReferrers(postIDs, r)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch referrers",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"post_id":        post.ID,
+		"from":           r.from.Format(statsDateLayout),
+		"to":             r.to.Format(statsDateLayout),
+		"granularity":    r.granularity,
+		"series":         series,
+		"totals":         sumStats(series),
+		"top_referrers":  referrers,
+		"lifetime_views": post.ViewCount,
+	})
+}
+
+// GetDashboard summarizes engagement across every post the caller appears on
+// as an author.
+func (h *AnalyticsHandler) GetDashboard(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	r, ok := parseStatsRange(c)
+	if !ok {
+		return
+	}
+
+	var posts []models.Post
+	if err := h.db.Select("id", "title", "slug", "status", "view_count", "like_count", "comment_count").
+		Scopes(bylinedBy(userID)).
+		Order("created_at DESC").
+		Find(&posts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch posts",
+		})
+		return
+	}
+
+	postIDs := make([]uuid.UUID, 0, len(posts))
+	for _, p := range posts {
+		postIDs = append(postIDs, p.ID)
+	}
+
+	var perPost []struct {
+		PostID        uuid.UUID
+		Views         int64
+		UniqueViewers int64
+		Likes         int64
+		Comments      int64
+	}
+	if len(postIDs) > 0 {
+		if err := h.db.Model(&models.PostDailyStat{}).
+			Select("post_id, SUM(views) AS views, SUM(unique_viewers) AS unique_viewers, SUM(likes) AS likes, SUM(comments) AS comments").
+			Where("post_id IN ? AND day BETWEEN ? AND ?", postIDs, r.from, r.to).
+			Group("post_id").
+			Scan(&perPost).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch stats",
+			})
+			return
+		}
+	}
+
+	totalsByPost := make(map[uuid.UUID]StatsPoint, len(perPost))
+	for _, row := range perPost {
+		totalsByPost[row.PostID] = StatsPoint{
+			Views:         row.Views,
+			UniqueViewers: row.UniqueViewers,
+			Likes:         row.Likes,
+			Comments:      row.Comments,
+		}
+	}
+
+	summaries := make([]gin.H, 0, len(posts))
+	for _, p := range posts {
+		t := totalsByPost[p.ID]
+		summaries = append(summaries, gin.H{
+			"id":             p.ID,
+			"title":          p.Title,
+			"slug":           p.Slug,
+			"status":         p.Status,
+			"views":          t.Views,
+			"unique_viewers": t.UniqueViewers,
+			"likes":          t.Likes,
+			"comments":       t.Comments,
+			"lifetime_views": p.ViewCount,
+		})
+	}
+
+	series, err := h.series(postIDs, r)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch stats",
+		})
+		return
+	}
+
+	referrers, err := h.topReferrers(postIDs, r)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch referrers",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"from":          r.from.Format(statsDateLayout),
+		"to":            r.to.Format(statsDateLayout),
+		"granularity":   r.granularity,
+		"series":        series,
+		"totals":        sumStats(series),
+		"top_referrers": referrers,
+		"posts":         summaries,
+	})
+}
+
+// series returns one point per period in the range, including empty periods.
+func (h *AnalyticsHandler) series(postIDs []uuid.UUID, r statsRange) ([]StatsPoint, error) {
+	var rows []StatsPoint
+	if len(postIDs) > 0 {
+		period := "day"
+		if r.granularity == "week" {
+			period = "date_trunc('week', day)::date"
+		}
+		if err := h.db.Model(&models.PostDailyStat{}).
+			Select(period+" AS period, SUM(views) AS views, SUM(unique_viewers) AS unique_viewers, SUM(likes) AS likes, SUM(comments) AS comments").
+			Where("post_id IN ? AND day BETWEEN ? AND ?", postIDs, r.from, r.to).
+			Group("period").
+			Order("period ASC").
+			Scan(&rows).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	byPeriod := make(map[string]StatsPoint, len(rows))
+	for _, row := range rows {
+		byPeriod[row.Period.UTC().Format(statsDateLayout)] = row
+	}
+
+	start, step := r.from, 24*time.Hour
+	if r.granularity == "week" {
+		start = startOfWeek(r.from)
+		step = 7 * 24 * time.Hour
+	}
+
+	points := []StatsPoint{}
+	for t := start; !t.After(r.to); t = t.Add(step) {
+		point, ok := byPeriod[t.Format(statsDateLayout)]
+		if !ok {
+			point = StatsPoint{}
+		}
+		point.Period = t
+		points = append(points, point)
+	}
+	return points, nil
+}
+
+func (h *AnalyticsHandler) topReferrers(postIDs []uuid.UUID, r statsRange) ([]ReferrerCount, error) {
+	referrers := []ReferrerCount{}
+	if len(postIDs) == 0 {
+		return referrers, nil
+	}
+
+	err := h.db.Model(&models.PostReferrerStat{}).
+		Select("domain, SUM(views) AS views").
+		Where("post_id IN ? AND day BETWEEN ? AND ?", postIDs, r.from, r.to).
+		Group("domain").
+		Order("views DESC").
+		Limit(10).
+		Scan(&referrers).Error
+	return referrers, err
+}
+
+func parseStatsRange(c *gin.Context) (statsRange, bool) {
+	var query StatsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid query parameters",
+		})
+		return statsRange{}, false
+	}
+
+	if query.Granularity != "day" && query.Granularity != "week" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "granularity must be day or week",
+		})
+		return statsRange{}, false
+	}
+
+	to := time.Now().UTC().Truncate(24 * time.Hour)
+	if query.To != "" {
+		parsed, err := time.Parse(statsDateLayout, query.To)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid to date, expected YYYY-MM-DD",
+			})
+			return statsRange{}, false
+		}
+		to = parsed
+	}
+
+	from := to.AddDate(0, 0, -(statsDefaultRange - 1))
+	if query.From != "" {
+		parsed, err := time.Parse(statsDateLayout, query.From)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid from date, expected YYYY-MM-DD",
+			})
+			return statsRange{}, false
+		}
+		from = parsed
+	}
+
+	if from.After(to) || to.Sub(from) > statsMaxRange*24*time.Hour {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid date range",
+		})
+		return statsRange{}, false
+	}
+
+	return statsRange{from: from, to: to, granularity: query.Granularity}, true
+}
+
+func sumStats(points []StatsPoint) StatsPoint {
+	var total StatsPoint
+	for _, p := range points {
+		total.Views += p.Views
+		total.UniqueViewers += p.UniqueViewers
+		total.Likes += p.Likes
+		total.Comments += p.Comments
+	}
+	return total
+}
+
+// startOfWeek returns the Monday on or before t, matching date_trunc('week').
+func startOfWeek(t time.Time) time.Time {
+	offset := (int(t.Weekday()) + 6) % 7
+	return t.AddDate(0, 0, -offset)
+}
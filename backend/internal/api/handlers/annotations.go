@@ -0,0 +1,355 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/yairfalse/modern-cloud-app/backend/internal/annotations"
+	"github.com/yairfalse/modern-cloud-app/backend/internal/api/middleware"
+	"github.com/yairfalse/modern-cloud-app/backend/internal/database/models"
+)
+
+// AnnotationHandler serves editorial annotations. They are only visible to
+// people who can edit the post and to editors, and are never embedded in
+// post responses.
+type AnnotationHandler struct {
+	db *gorm.DB
+}
+
+type CreateAnnotationRequest struct {
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+	Body  string `json:"body" binding:"required,min=1"`
+	// Version is the post version the offsets were taken from.
+	Version int `json:"version" binding:"required"`
+}
+
+type AnnotationBodyRequest struct {
+	Body string `json:"body" binding:"required,min=1"`
+}
+
+func NewAnnotationHandler(db *gorm.DB) *AnnotationHandler {
+	return &AnnotationHandler{db: db}
+}
+
+func (h *AnnotationHandler) GetAnnotations(c *gin.Context) {
+	post, _, ok := h.loadPost(c)
+	if !ok {
+		return
+	}
+
+	includeResolved, _ := strconv.ParseBool(c.Query("include_resolved"))
+
+	query := h.db.Preload("Author").
+		Preload("Replies", func(db *gorm.DB) *gorm.DB { return db.Order("created_at ASC") }).
+		Preload("Replies.Author").
+		Where("post_id = ? AND parent_id IS NULL", post.ID)
+	if !includeResolved {
+		query = query.Where("resolved = ?", false)
+	}
+
+	var list []models.Annotation
+	if err := query.Order("start_offset ASC, created_at ASC").Find(&list).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch annotations",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"annotations":  list,
+		"post_version": post.Version,
+	})
+}
+
+func (h *AnnotationHandler) CreateAnnotation(c *gin.Context) {
+	post, userID, ok := h.loadPost(c)
+	if !ok {
+		return
+	}
+
+	var req CreateAnnotationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if req.Version != post.Version {
+		c.Header("ETag", versionETag(post.Version))
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   "The post has changed since these offsets were taken",
+			"version": post.Version,
+		})
+		return
+	}
+
+	anchor, err := annotations.NewAnchor(post.Content, req.Start, req.End)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid range, start and end must select text within the content",
+		})
+		return
+	}
+
+	annotation := models.Annotation{
+		PostID:      post.ID,
+		AuthorID:    userID,
+		Body:        req.Body,
+		PostVersion: post.Version,
+		Start:       anchor.Start,
+		End:         anchor.End,
+		Quote:       anchor.Quote,
+		Prefix:      anchor.Prefix,
+		Suffix:      anchor.Suffix,
+	}
+	if err := h.db.Create(&annotation).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to create annotation",
+		})
+		return
+	}
+
+	h.db.Preload("Author").First(&annotation, "id = ?", annotation.ID)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"annotation": annotation,
+	})
+}
+
+func (h *AnnotationHandler) ReplyToAnnotation(c *gin.Context) {
+	post, userID, ok := h.loadPost(c)
+	if !ok {
+		return
+	}
+
+	parent, ok := h.loadAnnotation(c, post.ID)
+	if !ok {
+		return
+	}
+	if parent.ParentID != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Replies cannot be nested",
+		})
+		return
+	}
+
+	var req AnnotationBodyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	reply := models.Annotation{
+		PostID:      post.ID,
+		ParentID:    &parent.ID,
+		AuthorID:    userID,
+		Body:        req.Body,
+		PostVersion: post.Version,
+	}
+	if err := h.db.Create(&reply).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to create reply",
+		})
+		return
+	}
+
+	h.db.Preload("Author").First(&reply, "id = ?", reply.ID)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"annotation": reply,
+	})
+}
+
+func (h *AnnotationHandler) UpdateAnnotation(c *gin.Context) {
+	post, userID, ok := h.loadPost(c)
+	if !ok {
+		return
+	}
+
+	annotation, ok := h.loadAnnotation(c, post.ID)
+	if !ok {
+		return
+	}
+	if annotation.AuthorID != userID {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Only the author can edit an annotation",
+		})
+		return
+	}
+
+	var req AnnotationBodyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if err := h.db.Model(annotation).Update("body", req.Body).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to update annotation",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"annotation": annotation,
+	})
+}
+
+func (h *AnnotationHandler) DeleteAnnotation(c *gin.Context) {
+	post, userID, ok := h.loadPost(c)
+	if !ok {
+		return
+	}
+
+	annotation, ok := h.loadAnnotation(c, post.ID)
+	if !ok {
+		return
+	}
+	if annotation.AuthorID != userID && !middleware.IsEditor(c, h.db) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Only the author or an editor can delete an annotation",
+		})
+		return
+	}
+
+	if err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("parent_id = ?", annotation.ID).Delete(&models.Annotation{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(annotation).Error
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to delete annotation",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Annotation deleted successfully",
+	})
+}
+
+func (h *AnnotationHandler) ResolveAnnotation(c *gin.Context) {
+	h.setResolved(c, true)
+}
+
+func (h *AnnotationHandler) UnresolveAnnotation(c *gin.Context) {
+	h.setResolved(c, false)
+}
+
+func (h *AnnotationHandler) setResolved(c *gin.Context, resolved bool) {
+	post, userID, ok := h.loadPost(c)
+	if !ok {
+		return
+	}
+
+	annotation, ok := h.loadAnnotation(c, post.ID)
+	if !ok {
+		return
+	}
+	if annotation.ParentID != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Only top-level annotations can be resolved",
+		})
+		return
+	}
+
+	updates := map[string]interface{}{
+		"resolved":    resolved,
+		"resolved_by": nil,
+		"resolved_at": nil,
+	}
+	if resolved {
+		updates["resolved_by"] = userID
+		updates["resolved_at"] = time.Now().UTC()
+	}
+	if err := h.db.Model(annotation).Updates(updates).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to update annotation",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"annotation": annotation,
+	})
+}
+
+// loadPost returns the post in the URL if the caller can edit it or is an
+// editor.
+func (h *AnnotationHandler) loadPost(c *gin.Context) (*models.Post, uuid.UUID, bool) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return nil, uuid.Nil, false
+	}
+
+	postUUID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid post ID",
+		})
+		return nil, uuid.Nil, false
+	}
+
+	query := h.db.Select("posts.id", "posts.content", "posts.version")
+	if !middleware.IsEditor(c, h.db) {
+		query = query.Scopes(canEditPost(userID))
+	}
+
+	var post models.Post
+	if err := query.Where("posts.id = ?", postUUID).First(&post).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Post not found or not authorized",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch post",
+			})
+		}
+		return nil, uuid.Nil, false
+	}
+	return &post, userID, true
+}
+
+func (h *AnnotationHandler) loadAnnotation(c *gin.Context, postID uuid.UUID) (*models.Annotation, bool) {
+	annotationUUID, err := uuid.Parse(c.Param("annotationId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid annotation ID",
+		})
+		return nil, false
+	}
+
+	var annotation models.Annotation
+	if err := h.db.Where("id = ? AND post_id = ?", annotationUUID, postID).First(&annotation).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Annotation not found",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch annotation",
+			})
+		}
+		return nil, false
+	}
+	return &annotation, true
+}
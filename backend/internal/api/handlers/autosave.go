@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/yairfalse/modern-cloud-app/backend/internal/api/middleware"
+	"github.com/yairfalse/modern-cloud-app/backend/internal/database/models"
+)
+
+// AutosaveHandler keeps each editor's working copy of a post. Autosaves are
+// meant to be sent every few seconds, so they skip rendering, revisions and
+// hooks and never modify the post.
+type AutosaveHandler struct {
+	db *gorm.DB
+}
+
+type AutosaveRequest struct {
+	Title   string `json:"title"`
+	Content string `json:"content"`
+	Excerpt string `json:"excerpt"`
+	// Version is the post version the editor loaded before making changes.
+	Version int `json:"version" binding:"required"`
+}
+
+func NewAutosaveHandler(db *gorm.DB) *AutosaveHandler {
+	return &AutosaveHandler{db: db}
+}
+
+// SaveAutosave stores the caller's working copy. The copy is kept even when
+// the post was saved by someone else in the meantime, but the response is a
+// conflict so the editor can merge before saving.
+func (h *AutosaveHandler) SaveAutosave(c *gin.Context) {
+	post, userID, ok := h.loadPost(c)
+	if !ok {
+		return
+	}
+
+	var req AutosaveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	autosave := models.PostAutosave{
+		PostID:      post.ID,
+		UserID:      userID,
+		Title:       req.Title,
+		Content:     req.Content,
+		Excerpt:     req.Excerpt,
+		BaseVersion: req.Version,
+		SavedAt:     time.Now().UTC(),
+	}
+	if err := h.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "post_id"}, {Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"title", "content", "excerpt", "base_version", "saved_at"}),
+	}).Create(&autosave).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to autosave post",
+		})
+		return
+	}
+
+	c.Header("ETag", versionETag(post.Version))
+	if req.Version != post.Version {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":    "The post has been saved since this copy was started",
+			"version":  post.Version,
+			"saved_at": autosave.SavedAt,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"version":  post.Version,
+		"saved_at": autosave.SavedAt,
+	})
+}
+
+// GetAutosave returns the caller's working copy so unsaved work can be
+// recovered. Stale is set when the post has been saved since the copy was
+// started.
+func (h *AutosaveHandler) GetAutosave(c *gin.Context) {
+	post, userID, ok := h.loadPost(c)
+	if !ok {
+		return
+	}
+
+	var autosave models.PostAutosave
+	if err := h.db.Where("post_id = ? AND user_id = ?", post.ID, userID).First(&autosave).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "No autosave for this post",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch autosave",
+			})
+		}
+		return
+	}
+
+	c.Header("ETag", versionETag(post.Version))
+	c.JSON(http.StatusOK, gin.H{
+		"autosave": autosave,
+		"version":  post.Version,
+		"stale":    autosave.BaseVersion != post.Version,
+	})
+}
+
+// DeleteAutosave discards the caller's working copy.
+func (h *AutosaveHandler) DeleteAutosave(c *gin.Context) {
+	post, userID, ok := h.loadPost(c)
+	if !ok {
+		return
+	}
+
+	if err := discardAutosave(h.db, post.ID, userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to discard autosave",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Autosave discarded",
+	})
+}
+
+// loadPost returns the id and version of the post in the URL if the caller
+// can edit it.
+func (h *AutosaveHandler) loadPost(c *gin.Context) (*models.Post, uuid.UUID, bool) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return nil, uuid.Nil, false
+	}
+
+	postUUID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid post ID",
+		})
+		return nil, uuid.Nil, false
+	}
+
+	var post models.Post
+	if err := h.db.Scopes(canEditPost(userID)).
+		Select("posts.id", "posts.version").
+		Where("posts.id = ?", postUUID).
+		First(&post).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Post not found or not authorized",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch post",
+			})
+		}
+		return nil, uuid.Nil, false
+	}
+	return &post, userID, true
+}
+
+// discardAutosave removes a user's working copy of a post, typically once
+// their changes have been saved.
+func discardAutosave(db *gorm.DB, postID, userID uuid.UUID) error {
+	return db.Where("post_id = ? AND user_id = ?", postID, userID).Delete(&models.PostAutosave{}).Error
+}
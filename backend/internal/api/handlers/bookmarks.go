@@ -0,0 +1,544 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/yairfalse/modern-cloud-app/backend/internal/api/middleware"
+	"github.com/yairfalse/modern-cloud-app/backend/internal/database/models"
+)
+
+type BookmarkHandler struct {
+	db *gorm.DB
+}
+
+type SaveBookmarkRequest struct {
+	FolderID *uuid.UUID `json:"folder_id"`
+	Note     *string    `json:"note"`
+}
+
+type BookmarkFolderRequest struct {
+	Name string `json:"name" binding:"required,min=1,max=100"`
+}
+
+type ReadingProgressRequest struct {
+	Percent *float64 `json:"percent" binding:"required,min=0,max=100"`
+}
+
+type BookmarksQuery struct {
+	Page     int    `form:"page,default=1"`
+	Limit    int    `form:"limit,default=20"`
+	FolderID string `form:"folder_id"`
+}
+
+func NewBookmarkHandler(db *gorm.DB) *BookmarkHandler {
+	return &BookmarkHandler{db: db}
+}
+
+func (h *BookmarkHandler) GetBookmarks(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	var query BookmarksQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid query parameters",
+		})
+		return
+	}
+	if query.Limit > 50 {
+		query.Limit = 50
+	}
+	if query.Limit < 1 {
+		query.Limit = 20
+	}
+	if query.Page < 1 {
+		query.Page = 1
+	}
+
+	// Bookmarks of posts that have since been unpublished or made private are
+	// kept, but not listed while the caller cannot see the post.
+	db := h.db.Model(&models.Bookmark{}).
+		Joins("JOIN posts ON posts.id = bookmarks.post_id AND posts.deleted_at IS NULL").
+		Where("bookmarks.user_id = ? AND posts.status = ?", userID, models.PostStatusPublished).
+		Scopes(visibleTo(userID))
+
+	switch query.FolderID {
+	case "":
+	case "none":
+		db = db.Where("bookmarks.folder_id IS NULL")
+	default:
+		folderUUID, err := uuid.Parse(query.FolderID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid folder ID",
+			})
+			return
+		}
+		db = db.Where("bookmarks.folder_id = ?", folderUUID)
+	}
+
+	var total int64
+	db.Session(&gorm.Session{}).Count(&total)
+
+	var bookmarks []models.Bookmark
+	if err := db.Preload("Post").Preload("Post.Author").Preload("Folder").
+		Order("bookmarks.created_at DESC").
+		Offset((query.Page - 1) * query.Limit).
+		Limit(query.Limit).
+		Find(&bookmarks).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch bookmarks",
+		})
+		return
+	}
+
+	postIDs := make([]uuid.UUID, 0, len(bookmarks))
+	posts := make([]*models.Post, 0, len(bookmarks))
+	for _, b := range bookmarks {
+		postIDs = append(postIDs, b.PostID)
+		if b.Post != nil {
+			posts = append(posts, b.Post)
+		}
+	}
+	redactListed(c, posts)
+	progress := h.progressFor(userID, postIDs)
+
+	items := make([]gin.H, 0, len(bookmarks))
+	for _, b := range bookmarks {
+		item := gin.H{
+			"bookmark": b,
+			"progress": nil,
+		}
+		if p, ok := progress[b.PostID]; ok {
+			item["progress"] = p
+		}
+		items = append(items, item)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"bookmarks": items,
+		"pagination": gin.H{
+			"page":  query.Page,
+			"limit": query.Limit,
+			"total": total,
+			"pages": (total + int64(query.Limit) - 1) / int64(query.Limit),
+		},
+	})
+}
+
+// SaveBookmark bookmarks a post, or updates the folder and note of an
+// existing bookmark.
+func (h *BookmarkHandler) SaveBookmark(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	postUUID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid post ID",
+		})
+		return
+	}
+
+	var req SaveBookmarkRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	post, ok := h.loadPost(c, userID, postUUID)
+	if !ok {
+		return
+	}
+
+	if req.FolderID != nil && *req.FolderID != uuid.Nil {
+		var folder models.BookmarkFolder
+		if err := h.db.Where("id = ? AND user_id = ?", *req.FolderID, userID).First(&folder).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Folder not found",
+			})
+			return
+		}
+	}
+
+	var bookmark models.Bookmark
+	err = h.db.Where("user_id = ? AND post_id = ?", userID, post.ID).First(&bookmark).Error
+	status := http.StatusOK
+	switch {
+	case err == gorm.ErrRecordNotFound:
+		bookmark = models.Bookmark{
+			UserID: userID,
+			PostID: post.ID,
+		}
+		status = http.StatusCreated
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch bookmark",
+		})
+		return
+	}
+
+	if req.FolderID != nil {
+		bookmark.FolderID = req.FolderID
+		if *req.FolderID == uuid.Nil {
+			bookmark.FolderID = nil
+		}
+	}
+	if req.Note != nil {
+		bookmark.Note = *req.Note
+	}
+
+	if err := h.db.Save(&bookmark).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to save bookmark",
+		})
+		return
+	}
+
+	c.JSON(status, gin.H{
+		"bookmark": bookmark,
+	})
+}
+
+func (h *BookmarkHandler) DeleteBookmark(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	postUUID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid post ID",
+		})
+		return
+	}
+
+	result := h.db.Where("user_id = ? AND post_id = ?", userID, postUUID).Delete(&models.Bookmark{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to delete bookmark",
+		})
+		return
+	}
+
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Bookmark not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Bookmark deleted successfully",
+	})
+}
+
+// UpdateProgress records how far the caller has read a post. It is a single
+// upsert so clients can call it frequently while the reader scrolls.
+func (h *BookmarkHandler) UpdateProgress(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	postUUID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid post ID",
+		})
+		return
+	}
+
+	var req ReadingProgressRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	post, ok := h.loadPost(c, userID, postUUID)
+	if !ok {
+		return
+	}
+
+	progress := models.ReadingProgress{
+		UserID:     userID,
+		PostID:     post.ID,
+		Percent:    *req.Percent,
+		LastReadAt: time.Now().UTC(),
+	}
+
+	if err := h.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "post_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"percent", "last_read_at"}),
+	}).Create(&progress).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to save progress",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"progress": progress,
+	})
+}
+
+func (h *BookmarkHandler) GetFolders(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	var folders []models.BookmarkFolder
+	if err := h.db.Where("user_id = ?", userID).Order("name ASC").Find(&folders).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch folders",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"folders": folders,
+	})
+}
+
+func (h *BookmarkHandler) CreateFolder(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	var req BookmarkFolderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	var existing int64
+	h.db.Model(&models.BookmarkFolder{}).Where("user_id = ? AND name = ?", userID, req.Name).Count(&existing)
+	if existing > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Folder already exists",
+		})
+		return
+	}
+
+	folder := models.BookmarkFolder{
+		UserID: userID,
+		Name:   req.Name,
+	}
+	if err := h.db.Create(&folder).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to create folder",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"folder": folder,
+	})
+}
+
+func (h *BookmarkHandler) UpdateFolder(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	folderUUID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid folder ID",
+		})
+		return
+	}
+
+	var req BookmarkFolderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request data",
+		})
+		return
+	}
+
+	var folder models.BookmarkFolder
+	if err := h.db.Where("id = ? AND user_id = ?", folderUUID, userID).First(&folder).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Folder not found",
+		})
+		return
+	}
+
+	if err := h.db.Model(&folder).Update("name", req.Name).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to update folder",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"folder": folder,
+	})
+}
+
+// DeleteFolder removes a folder; its bookmarks are kept without a folder.
+func (h *BookmarkHandler) DeleteFolder(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	folderUUID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid folder ID",
+		})
+		return
+	}
+
+	var rowsAffected int64
+	if err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Bookmark{}).
+			Where("user_id = ? AND folder_id = ?", userID, folderUUID).
+			Update("folder_id", nil).Error; err != nil {
+			return err
+		}
+		result := tx.Where("id = ? AND user_id = ?", folderUUID, userID).Delete(&models.BookmarkFolder{})
+		rowsAffected = result.RowsAffected
+		return result.Error
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to delete folder",
+		})
+		return
+	}
+
+	if rowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Folder not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Folder deleted successfully",
+	})
+}
+
+// loadPost returns the id of a published post the caller may bookmark or
+// track progress on. Private posts are reported as missing to anyone who
+// cannot edit them, as GetPost does.
+func (h *BookmarkHandler) loadPost(c *gin.Context, userID, postID uuid.UUID) (*models.Post, bool) {
+	var post models.Post
+	if err := h.db.Select("id").Scopes(visibleTo(userID)).
+		Where("posts.id = ? AND posts.status = ?", postID, models.PostStatusPublished).
+		First(&post).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Post not found",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch post",
+			})
+		}
+		return nil, false
+	}
+	return &post, true
+}
+
+func (h *BookmarkHandler) progressFor(userID uuid.UUID, postIDs []uuid.UUID) map[uuid.UUID]models.ReadingProgress {
+	progress := make(map[uuid.UUID]models.ReadingProgress)
+	if len(postIDs) == 0 {
+		return progress
+	}
+
+	var rows []models.ReadingProgress
+	h.db.Where("user_id = ? AND post_id IN ?", userID, postIDs).Find(&rows)
+	for _, row := range rows {
+		progress[row.PostID] = row
+	}
+	return progress
+}
+
+// markViewerState sets the bookmark flag and read progress on posts for an
+// authenticated viewer. It leaves the posts untouched for anonymous callers.
+func markViewerState(db *gorm.DB, userID uuid.UUID, posts []*models.Post) {
+	if userID == uuid.Nil || len(posts) == 0 {
+		return
+	}
+
+	postIDs := make([]uuid.UUID, 0, len(posts))
+	for _, p := range posts {
+		postIDs = append(postIDs, p.ID)
+	}
+
+	var bookmarked []uuid.UUID
+	db.Model(&models.Bookmark{}).
+		Where("user_id = ? AND post_id IN ?", userID, postIDs).
+		Pluck("post_id", &bookmarked)
+	isBookmarked := make(map[uuid.UUID]bool, len(bookmarked))
+	for _, id := range bookmarked {
+		isBookmarked[id] = true
+	}
+
+	var progress []models.ReadingProgress
+	db.Where("user_id = ? AND post_id IN ?", userID, postIDs).Find(&progress)
+	percent := make(map[uuid.UUID]float64, len(progress))
+	for _, p := range progress {
+		percent[p.PostID] = p.Percent
+	}
+
+	for _, p := range posts {
+		flag := isBookmarked[p.ID]
+		p.Bookmarked = &flag
+		if value, ok := percent[p.ID]; ok {
+			p.ReadProgress = &value
+		}
+	}
+}
@@ -0,0 +1,230 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/yairfalse/modern-cloud-app/backend/internal/api/middleware"
+	"github.com/yairfalse/modern-cloud-app/backend/internal/bulk"
+	"github.com/yairfalse/modern-cloud-app/backend/internal/config"
+	"github.com/yairfalse/modern-cloud-app/backend/internal/database/models"
+)
+
+type BulkHandler struct {
+	db        *gorm.DB
+	processor *bulk.Processor
+	syncLimit int
+	maxItems  int
+}
+
+type BulkPostsRequest struct {
+	Action   string           `json:"action" binding:"required"`
+	IDs      []uuid.UUID      `json:"ids"`
+	Filter   *BulkPostsFilter `json:"filter"`
+	Tags     []string         `json:"tags"`
+	AuthorID *uuid.UUID       `json:"author_id"`
+}
+
+// BulkPostsFilter selects posts by the same criteria as GetPosts. At least
+// one criterion is required so a request cannot match every post by accident.
+type BulkPostsFilter struct {
+	Status   string     `json:"status"`
+	AuthorID *uuid.UUID `json:"author_id"`
+	Tag      string     `json:"tag"`
+	Category string     `json:"category"`
+	Search   string     `json:"search"`
+}
+
+var (
+	errEmptyFilter  = errors.New("filter must set at least one of status, author_id, tag, category or search")
+	errFilterStatus = errors.New("invalid status in filter")
+)
+
+func NewBulkHandler(db *gorm.DB, processor *bulk.Processor, cfg config.BulkConfig) *BulkHandler {
+	return &BulkHandler{
+		db:        db,
+		processor: processor,
+		syncLimit: cfg.SyncLimit,
+		maxItems:  cfg.MaxItems,
+	}
+}
+
+// BulkPosts applies one action to a list of posts. Small requests run in a
+// single transaction and return the result directly; larger ones are queued
+// as a job whose progress can be polled.
+func (h *BulkHandler) BulkPosts(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	var req BulkPostsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if (len(req.IDs) == 0) == (req.Filter == nil) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Provide either ids or filter",
+		})
+		return
+	}
+
+	op := bulk.Operation{
+		Action: models.BulkAction(req.Action),
+		Params: models.BulkParams{
+			Tags:     req.Tags,
+			AuthorID: req.AuthorID,
+		},
+		ActorID: userID,
+	}
+	if err := op.Validate(h.db); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	postIDs := uniqueIDs(req.IDs)
+	if req.Filter != nil {
+		var err error
+		postIDs, err = h.matchPosts(*req.Filter, op.Action == models.BulkActionRestore)
+		if errors.Is(err, errEmptyFilter) || errors.Is(err, errFilterStatus) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to resolve filter",
+			})
+			return
+		}
+	}
+
+	if len(postIDs) > h.maxItems {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Too many posts for a single bulk operation",
+			"limit": h.maxItems,
+			"total": len(postIDs),
+		})
+		return
+	}
+
+	if len(postIDs) > h.syncLimit {
+		job := models.BulkJob{
+			CreatedBy: userID,
+			Action:    op.Action,
+			Params:    op.Params,
+			PostIDs:   postIDs,
+		}
+		if err := h.processor.Submit(&job); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to queue bulk operation",
+			})
+			return
+		}
+
+		c.Header("Location", c.Request.URL.Path+"/"+job.ID.String())
+		c.JSON(http.StatusAccepted, gin.H{
+			"job": job,
+		})
+		return
+	}
+
+	failures, err := h.processor.Apply(op, postIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to apply bulk operation",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"action":    op.Action,
+		"total":     len(postIDs),
+		"succeeded": len(postIDs) - len(failures),
+		"failures":  failures,
+	})
+}
+
+func (h *BulkHandler) GetBulkJob(c *gin.Context) {
+	jobUUID, err := uuid.Parse(c.Param("jobId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid job ID",
+		})
+		return
+	}
+
+	var job models.BulkJob
+	if err := h.db.First(&job, "id = ?", jobUUID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Job not found",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch job",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"job": job,
+	})
+}
+
+// matchPosts resolves a filter to post IDs. Restores match only deleted
+// posts; every other action matches only live ones.
+func (h *BulkHandler) matchPosts(filter BulkPostsFilter, deleted bool) ([]uuid.UUID, error) {
+	if filter.Status == "" && filter.AuthorID == nil && filter.Tag == "" && filter.Category == "" && filter.Search == "" {
+		return nil, errEmptyFilter
+	}
+	if filter.Status != "" && !models.PostStatus(filter.Status).Valid() {
+		return nil, errFilterStatus
+	}
+
+	db := h.db.Model(&models.Post{})
+	if deleted {
+		db = db.Unscoped().Where("posts.deleted_at IS NOT NULL")
+	}
+
+	if filter.Status != "" {
+		db = db.Where("posts.status = ?", filter.Status)
+	}
+	if filter.AuthorID != nil {
+		db = db.Scopes(bylinedBy(*filter.AuthorID))
+	}
+	if filter.Search != "" {
+		searchTerm := "%" + filter.Search + "%"
+		db = db.Where("title ILIKE ? OR content ILIKE ?", searchTerm, searchTerm)
+	}
+	if filter.Tag != "" {
+		db = db.Joins("JOIN post_tags ON posts.id = post_tags.post_id").
+			Joins("JOIN tags ON post_tags.tag_id = tags.id").
+			Where("tags.slug = ?", filter.Tag)
+	}
+	if filter.Category != "" {
+		db = db.Scopes(inCategory(filter.Category))
+	}
+
+	var ids []uuid.UUID
+	if err := db.Order("posts.created_at ASC").Pluck("posts.id", &ids).Error; err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
@@ -0,0 +1,486 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/yairfalse/modern-cloud-app/backend/internal/database/models"
+)
+
+var errCategoryNotFound = errors.New("category not found")
+
+type CategoryHandler struct {
+	db *gorm.DB
+}
+
+type CreateCategoryRequest struct {
+	Name        string     `json:"name" binding:"required,min=1,max=100"`
+	Slug        string     `json:"slug"`
+	Description string     `json:"description"`
+	ParentID    *uuid.UUID `json:"parent_id"`
+	Position    int        `json:"position"`
+}
+
+type UpdateCategoryRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type MoveCategoryRequest struct {
+	ParentID *uuid.UUID `json:"parent_id"`
+	Position *int       `json:"position"`
+}
+
+// CategoryNode is a category in the tree response, with a post count that
+// includes every descendant category.
+type CategoryNode struct {
+	models.Category
+	PostCount int64           `json:"post_count"`
+	Children  []*CategoryNode `json:"children"`
+}
+
+func NewCategoryHandler(db *gorm.DB) *CategoryHandler {
+	return &CategoryHandler{db: db}
+}
+
+func (h *CategoryHandler) GetCategories(c *gin.Context) {
+	var categories []models.Category
+	if err := h.db.Order("position ASC, name ASC").Find(&categories).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch categories",
+		})
+		return
+	}
+
+	counts, err := h.postCounts()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to count posts",
+		})
+		return
+	}
+
+	nodes := make(map[uuid.UUID]*CategoryNode, len(categories))
+	for _, category := range categories {
+		nodes[category.ID] = &CategoryNode{
+			Category:  category,
+			PostCount: counts[category.ID],
+			Children:  []*CategoryNode{},
+		}
+	}
+
+	roots := []*CategoryNode{}
+	for _, category := range categories {
+		node := nodes[category.ID]
+		if category.ParentID != nil {
+			if parent, ok := nodes[*category.ParentID]; ok {
+				parent.Children = append(parent.Children, node)
+				continue
+			}
+		}
+		roots = append(roots, node)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"categories": roots,
+	})
+}
+
+func (h *CategoryHandler) GetCategory(c *gin.Context) {
+	category, ok := h.loadCategory(c)
+	if !ok {
+		return
+	}
+
+	var children []models.Category
+	if err := h.db.Where("parent_id = ?", category.ID).
+		Order("position ASC, name ASC").
+		Find(&children).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch categories",
+		})
+		return
+	}
+
+	var ancestors []models.Category
+	if ids := ancestorIDs(category); len(ids) > 0 {
+		h.db.Where("id IN ?", ids).Find(&ancestors)
+		order := make(map[uuid.UUID]int, len(ids))
+		for i, id := range ids {
+			order[id] = i
+		}
+		sort.Slice(ancestors, func(i, j int) bool { return order[ancestors[i].ID] < order[ancestors[j].ID] })
+	}
+
+	counts, err := h.postCounts()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to count posts",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"category":   category,
+		"post_count": counts[category.ID],
+		"children":   children,
+		"ancestors":  ancestors,
+	})
+}
+
+func (h *CategoryHandler) CreateCategory(c *gin.Context) {
+	var req CreateCategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	slug := generateSlug(req.Slug)
+	if slug == "" {
+		slug = generateSlug(req.Name)
+	}
+	if slug == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid slug",
+		})
+		return
+	}
+
+	var existing int64
+	h.db.Unscoped().Model(&models.Category{}).Where("slug = ?", slug).Count(&existing)
+	if existing > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Category slug already in use",
+		})
+		return
+	}
+
+	category := models.Category{
+		ID:          uuid.New(),
+		Name:        req.Name,
+		Slug:        slug,
+		Description: req.Description,
+		ParentID:    req.ParentID,
+		Position:    req.Position,
+	}
+
+	category.Path = "/" + category.ID.String() + "/"
+	if req.ParentID != nil {
+		var parent models.Category
+		if err := h.db.First(&parent, "id = ?", *req.ParentID).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Parent category not found",
+			})
+			return
+		}
+		category.Path = parent.ChildPath(category.ID)
+	}
+
+	if err := h.db.Create(&category).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to create category",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"category": category,
+	})
+}
+
+func (h *CategoryHandler) UpdateCategory(c *gin.Context) {
+	category, ok := h.loadCategory(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateCategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request data",
+		})
+		return
+	}
+
+	updates := make(map[string]interface{})
+	if req.Name != "" {
+		updates["name"] = req.Name
+	}
+	if req.Description != "" {
+		updates["description"] = req.Description
+	}
+
+	if err := h.db.Model(category).Updates(updates).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to update category",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"category": category,
+	})
+}
+
+// MoveCategory re-parents a category, carrying its whole subtree with it. A
+// nil parent_id moves the category to the root.
+func (h *CategoryHandler) MoveCategory(c *gin.Context) {
+	category, ok := h.loadCategory(c)
+	if !ok {
+		return
+	}
+
+	var req MoveCategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request data",
+		})
+		return
+	}
+
+	newPath := "/" + category.ID.String() + "/"
+	if req.ParentID != nil {
+		var parent models.Category
+		if err := h.db.First(&parent, "id = ?", *req.ParentID).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Parent category not found",
+			})
+			return
+		}
+		if strings.HasPrefix(parent.Path, category.Path) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Cannot move a category into its own subtree",
+			})
+			return
+		}
+		newPath = parent.ChildPath(category.ID)
+	}
+
+	oldPath := category.Path
+	if err := h.db.Transaction(func(tx *gorm.DB) error {
+		updates := map[string]interface{}{
+			"parent_id": req.ParentID,
+		}
+		if req.Position != nil {
+			updates["position"] = *req.Position
+		}
+		if err := tx.Model(category).Updates(updates).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Category{}).
+			Where("path LIKE ?", oldPath+"%").
+			UpdateColumn("path", gorm.Expr("? || substr(path, ?)", newPath, len(oldPath)+1)).Error
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to move category",
+		})
+		return
+	}
+
+	if err := h.db.First(category, "id = ?", category.ID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to load category",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"category": category,
+	})
+}
+
+func (h *CategoryHandler) DeleteCategory(c *gin.Context) {
+	category, ok := h.loadCategory(c)
+	if !ok {
+		return
+	}
+
+	var children int64
+	h.db.Model(&models.Category{}).Where("parent_id = ?", category.ID).Count(&children)
+	if children > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Category has subcategories; move or delete them first",
+		})
+		return
+	}
+
+	if err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Post{}).Where("category_id = ?", category.ID).
+			UpdateColumn("category_id", nil).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM post_categories WHERE category_id = ?", category.ID).Error; err != nil {
+			return err
+		}
+		return tx.Delete(category).Error
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to delete category",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Category deleted successfully",
+	})
+}
+
+func (h *CategoryHandler) loadCategory(c *gin.Context) (*models.Category, bool) {
+	id := c.Param("id")
+
+	query := h.db.Model(&models.Category{})
+	if categoryUUID, err := uuid.Parse(id); err == nil {
+		query = query.Where("id = ?", categoryUUID)
+	} else {
+		query = query.Where("slug = ?", id)
+	}
+
+	var category models.Category
+	if err := query.First(&category).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Category not found",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch category",
+			})
+		}
+		return nil, false
+	}
+
+	return &category, true
+}
+
+// postCounts returns the number of distinct published posts in each category
+// or any of its descendants, counting both primary and secondary placement.
+func (h *CategoryHandler) postCounts() (map[uuid.UUID]int64, error) {
+	var rows []struct {
+		ID    uuid.UUID
+		Count int64
+	}
+
+	err := h.db.Raw(`
+		SELECT c.id, COUNT(DISTINCT pc.post_id) AS count
+		FROM categories c
+		JOIN categories d ON d.path LIKE c.path || '%' AND d.deleted_at IS NULL
+		JOIN (
+			SELECT p.id AS post_id, p.category_id
+			FROM posts p
+			WHERE p.category_id IS NOT NULL AND p.status = ? AND p.visibility IN ? AND p.deleted_at IS NULL
+			UNION
+			SELECT pcat.post_id, pcat.category_id
+			FROM post_categories pcat
+			JOIN posts p ON p.id = pcat.post_id
+			WHERE p.status = ? AND p.visibility IN ? AND p.deleted_at IS NULL
+		) pc ON pc.category_id = d.id
+		WHERE c.deleted_at IS NULL
+		GROUP BY c.id`,
+		models.PostStatusPublished, listedVisibilities, models.PostStatusPublished, listedVisibilities,
+	).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[uuid.UUID]int64, len(rows))
+	for _, row := range rows {
+		counts[row.ID] = row.Count
+	}
+	return counts, nil
+}
+
+// inCategory limits a posts query to posts whose primary or secondary
+// category is the category with the given slug or one of its descendants.
+func inCategory(slug string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		subtree := "SELECT d.id FROM categories d JOIN categories c ON d.path LIKE c.path || '%' " +
+			"WHERE c.slug = ? AND c.deleted_at IS NULL AND d.deleted_at IS NULL"
+		return db.Where("posts.category_id IN ("+subtree+") OR EXISTS (SELECT 1 FROM post_categories pcat WHERE pcat.post_id = posts.id AND pcat.category_id IN ("+subtree+"))",
+			slug, slug)
+	}
+}
+
+// preloadTaxonomy loads the tags and categories of a post.
+func preloadTaxonomy(db *gorm.DB) *gorm.DB {
+	return db.Preload("Tags").
+		Preload("Category").
+		Preload("SecondaryCategories")
+}
+
+// setPostCategories assigns the primary category and replaces the secondary
+// categories. A nil primary or secondaries leaves that part unchanged, and
+// uuid.Nil clears the primary category.
+func setPostCategories(tx *gorm.DB, post *models.Post, primary *uuid.UUID, secondaries []uuid.UUID) error {
+	if primary != nil {
+		var categoryID *uuid.UUID
+		if *primary != uuid.Nil {
+			var count int64
+			if err := tx.Model(&models.Category{}).Where("id = ?", *primary).Count(&count).Error; err != nil {
+				return err
+			}
+			if count == 0 {
+				return errCategoryNotFound
+			}
+			categoryID = primary
+		}
+		if err := tx.Model(post).UpdateColumn("category_id", categoryID).Error; err != nil {
+			return err
+		}
+		post.CategoryID = categoryID
+	}
+
+	if secondaries != nil {
+		var categories []models.Category
+		if len(secondaries) > 0 {
+			if err := tx.Where("id IN ?", secondaries).Find(&categories).Error; err != nil {
+				return err
+			}
+			if len(categories) != len(uniqueIDs(secondaries)) {
+				return errCategoryNotFound
+			}
+		}
+		association := tx.Model(post).Association("SecondaryCategories")
+		if len(categories) == 0 {
+			if err := association.Clear(); err != nil {
+				return err
+			}
+		} else if err := association.Replace(categories); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func ancestorIDs(category *models.Category) []uuid.UUID {
+	var ids []uuid.UUID
+	for _, part := range strings.Split(strings.Trim(category.Path, "/"), "/") {
+		id, err := uuid.Parse(part)
+		if err != nil || id == category.ID {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func uniqueIDs(ids []uuid.UUID) []uuid.UUID {
+	seen := make(map[uuid.UUID]bool, len(ids))
+	result := make([]uuid.UUID, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			result = append(result, id)
+		}
+	}
+	return result
+}
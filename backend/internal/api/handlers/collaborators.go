@@ -0,0 +1,406 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/yairfalse/modern-cloud-app/backend/internal/api/middleware"
+	"github.com/yairfalse/modern-cloud-app/backend/internal/database/models"
+)
+
+type CollaboratorHandler struct {
+	db *gorm.DB
+}
+
+type InviteCollaboratorRequest struct {
+	UserID   *uuid.UUID `json:"user_id"`
+	Username string     `json:"username"`
+	Role     string     `json:"role" binding:"required"`
+}
+
+type UpdateCollaboratorRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+func NewCollaboratorHandler(db *gorm.DB) *CollaboratorHandler {
+	return &CollaboratorHandler{db: db}
+}
+
+// canEditPost limits a posts query to posts the user authored or collaborates
+// on with any accepted role.
+func canEditPost(userID uuid.UUID) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("posts.author_id = ? OR EXISTS (SELECT 1 FROM post_collaborators pc WHERE pc.post_id = posts.id AND pc.user_id = ? AND pc.status = ?)",
+			userID, userID, models.CollaboratorStatusAccepted)
+	}
+}
+
+// ownsPost limits a posts query to posts the user authored or co-owns.
+func ownsPost(userID uuid.UUID) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("posts.author_id = ? OR EXISTS (SELECT 1 FROM post_collaborators pc WHERE pc.post_id = posts.id AND pc.user_id = ? AND pc.status = ? AND pc.role = ?)",
+			userID, userID, models.CollaboratorStatusAccepted, models.CollaboratorRoleOwner)
+	}
+}
+
+// bylinedBy limits a posts query to posts the user appears on as an author,
+// which excludes editor collaborators.
+func bylinedBy(userID uuid.UUID) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("posts.author_id = ? OR EXISTS (SELECT 1 FROM post_collaborators pc WHERE pc.post_id = posts.id AND pc.user_id = ? AND pc.status = ? AND pc.role IN ?)",
+			userID, userID, models.CollaboratorStatusAccepted,
+			[]models.CollaboratorRole{models.CollaboratorRoleOwner, models.CollaboratorRoleCoAuthor})
+	}
+}
+
+// preloadByline loads the primary author and the accepted co-authors. The
+// author's own owner row is left out so they are not listed twice.
+func preloadByline(db *gorm.DB) *gorm.DB {
+	return db.Preload("Author").
+		Preload("CoAuthors", "status = ? AND role IN ? AND user_id <> (SELECT author_id FROM posts WHERE posts.id = post_collaborators.post_id)",
+			models.CollaboratorStatusAccepted,
+			[]models.CollaboratorRole{models.CollaboratorRoleOwner, models.CollaboratorRoleCoAuthor}).
+		Preload("CoAuthors.User")
+}
+
+func (h *CollaboratorHandler) GetCollaborators(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	post, ok := h.loadPost(c, canEditPost(userID))
+	if !ok {
+		return
+	}
+
+	var collaborators []models.PostCollaborator
+	if err := h.db.Preload("User").
+		Where("post_id = ?", post.ID).
+		Order("created_at ASC").
+		Find(&collaborators).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch collaborators",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"collaborators": collaborators,
+	})
+}
+
+func (h *CollaboratorHandler) InviteCollaborator(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	var req InviteCollaboratorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	role := models.CollaboratorRole(req.Role)
+	if !role.Valid() {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid role",
+		})
+		return
+	}
+
+	post, ok := h.loadPost(c, ownsPost(userID))
+	if !ok {
+		return
+	}
+
+	var invitee models.User
+	query := h.db.Model(&models.User{})
+	switch {
+	case req.UserID != nil:
+		query = query.Where("id = ?", *req.UserID)
+	case req.Username != "":
+		query = query.Where("username = ?", req.Username)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "user_id or username is required",
+		})
+		return
+	}
+	if err := query.First(&invitee).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "User not found",
+		})
+		return
+	}
+
+	if invitee.ID == post.AuthorID {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "User is already the author of this post",
+		})
+		return
+	}
+
+	var collaborator models.PostCollaborator
+	err := h.db.Where("post_id = ? AND user_id = ?", post.ID, invitee.ID).First(&collaborator).Error
+	switch {
+	case err == nil && collaborator.Status != models.CollaboratorStatusDeclined:
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "User is already invited to this post",
+		})
+		return
+	case err == nil:
+		collaborator.Role = role
+		collaborator.Status = models.CollaboratorStatusPending
+		collaborator.InvitedBy = &userID
+		collaborator.AcceptedAt = nil
+		err = h.db.Save(&collaborator).Error
+	case err == gorm.ErrRecordNotFound:
+		collaborator = models.PostCollaborator{
+			PostID:    post.ID,
+			UserID:    invitee.ID,
+			Role:      role,
+			Status:    models.CollaboratorStatusPending,
+			InvitedBy: &userID,
+		}
+		err = h.db.Create(&collaborator).Error
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to invite collaborator",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"collaborator": collaborator,
+	})
+}
+
+func (h *CollaboratorHandler) UpdateCollaborator(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	collaboratorUserID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid user ID",
+		})
+		return
+	}
+
+	var req UpdateCollaboratorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request data",
+		})
+		return
+	}
+
+	role := models.CollaboratorRole(req.Role)
+	if !role.Valid() {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid role",
+		})
+		return
+	}
+
+	post, ok := h.loadPost(c, ownsPost(userID))
+	if !ok {
+		return
+	}
+
+	result := h.db.Model(&models.PostCollaborator{}).
+		Where("post_id = ? AND user_id = ?", post.ID, collaboratorUserID).
+		Update("role", role)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to update collaborator",
+		})
+		return
+	}
+
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Collaborator not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Collaborator updated successfully",
+	})
+}
+
+// RemoveCollaborator lets an owner remove anyone, and any collaborator leave.
+func (h *CollaboratorHandler) RemoveCollaborator(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	collaboratorUserID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid user ID",
+		})
+		return
+	}
+
+	scope := ownsPost(userID)
+	if collaboratorUserID == userID {
+		scope = canEditPost(userID)
+	}
+
+	post, ok := h.loadPost(c, scope)
+	if !ok {
+		return
+	}
+
+	result := h.db.Where("post_id = ? AND user_id = ?", post.ID, collaboratorUserID).
+		Delete(&models.PostCollaborator{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to remove collaborator",
+		})
+		return
+	}
+
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Collaborator not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Collaborator removed successfully",
+	})
+}
+
+func (h *CollaboratorHandler) GetInvitations(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	var invitations []models.PostCollaborator
+	if err := h.db.Preload("Post").
+		Where("user_id = ? AND status = ?", userID, models.CollaboratorStatusPending).
+		Order("created_at DESC").
+		Find(&invitations).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch invitations",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"invitations": invitations,
+	})
+}
+
+func (h *CollaboratorHandler) AcceptInvitation(c *gin.Context) {
+	h.respondToInvitation(c, models.CollaboratorStatusAccepted)
+}
+
+func (h *CollaboratorHandler) DeclineInvitation(c *gin.Context) {
+	h.respondToInvitation(c, models.CollaboratorStatusDeclined)
+}
+
+func (h *CollaboratorHandler) respondToInvitation(c *gin.Context, status models.CollaboratorStatus) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	postUUID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid post ID",
+		})
+		return
+	}
+
+	updates := map[string]interface{}{
+		"status": status,
+	}
+	if status == models.CollaboratorStatusAccepted {
+		updates["accepted_at"] = time.Now().UTC()
+	}
+
+	result := h.db.Model(&models.PostCollaborator{}).
+		Where("post_id = ? AND user_id = ? AND status = ?", postUUID, userID, models.CollaboratorStatusPending).
+		Updates(updates)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to update invitation",
+		})
+		return
+	}
+
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Invitation not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Invitation " + string(status),
+	})
+}
+
+func (h *CollaboratorHandler) loadPost(c *gin.Context, scope func(*gorm.DB) *gorm.DB) (*models.Post, bool) {
+	postUUID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid post ID",
+		})
+		return nil, false
+	}
+
+	var post models.Post
+	if err := h.db.Scopes(scope).Where("posts.id = ?", postUUID).First(&post).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Post not found or not authorized",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch post",
+			})
+		}
+		return nil, false
+	}
+
+	return &post, true
+}
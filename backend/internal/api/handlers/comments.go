@@ -9,10 +9,12 @@ import (
 
 	"github.com/yairfalse/modern-cloud-app/backend/internal/api/middleware"
 	"github.com/yairfalse/modern-cloud-app/backend/internal/database/models"
+	"github.com/yairfalse/modern-cloud-app/backend/internal/duplicates"
 )
 
 type CommentHandler struct {
-	db *gorm.DB
+	db         *gorm.DB
+	duplicates *duplicates.Detector
 }
 
 type CreateCommentRequest struct {
@@ -24,8 +26,14 @@ type UpdateCommentRequest struct {
 	Content string `json:"content" binding:"required,min=1"`
 }
 
-func NewCommentHandler(db *gorm.DB) *CommentHandler {
-	return &CommentHandler{db: db}
+func NewCommentHandler(db *gorm.DB, detector *duplicates.Detector) *CommentHandler {
+	return &CommentHandler{db: db, duplicates: detector}
+}
+
+// preloadComments loads a post's comments, leaving out those held for
+// moderation.
+func preloadComments(db *gorm.DB) *gorm.DB {
+	return db.Preload("Comments", "held = ?", false).Preload("Comments.User")
 }
 
 func (h *CommentHandler) GetComments(c *gin.Context) {
@@ -39,8 +47,9 @@ func (h *CommentHandler) GetComments(c *gin.Context) {
 	}
 
 	var comments []models.Comment
-	if err := h.db.Where("post_id = ? AND parent_id IS NULL", postUUID).
+	if err := h.db.Where("post_id = ? AND parent_id IS NULL AND held = ?", postUUID, false).
 		Preload("User").
+		Preload("Replies", "held = ?", false).
 		Preload("Replies.User").
 		Order("created_at ASC").
 		Find(&comments).Error; err != nil {
@@ -101,13 +110,21 @@ func (h *CommentHandler) CreateComment(c *gin.Context) {
 	}
 
 	comment := models.Comment{
+		ID:       uuid.New(),
 		PostID:   postUUID,
 		UserID:   userID,
 		ParentID: req.ParentID,
 		Content:  req.Content,
 	}
 
-	if err := h.db.Create(&comment).Error; err != nil {
+	if err := h.db.Transaction(func(tx *gorm.DB) error {
+		held, err := h.checkDuplicate(tx, comment.ID, comment.Content)
+		if err != nil {
+			return err
+		}
+		comment.Held = held
+		return tx.Create(&comment).Error
+	}); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to create comment",
 		})
@@ -121,6 +138,14 @@ func (h *CommentHandler) CreateComment(c *gin.Context) {
 		return
 	}
 
+	c.Header("ETag", versionETag(comment.Version))
+	if comment.Held {
+		c.JSON(http.StatusCreated, gin.H{
+			"comment": comment,
+			"message": "Comment is awaiting moderation",
+		})
+		return
+	}
 	c.JSON(http.StatusCreated, gin.H{
 		"comment": comment,
 	})
@@ -158,6 +183,11 @@ func (h *CommentHandler) UpdateComment(c *gin.Context) {
 		return
 	}
 
+	if !checkIfMatch(c, comment.Version) {
+		h.respondCommentConflict(c, comment.ID)
+		return
+	}
+
 	var req UpdateCommentRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -166,7 +196,34 @@ func (h *CommentHandler) UpdateComment(c *gin.Context) {
 		return
 	}
 
-	if err := h.db.Model(&comment).Update("content", req.Content).Error; err != nil {
+	if err := h.db.Transaction(func(tx *gorm.DB) error {
+		updates := map[string]interface{}{"content": req.Content}
+		held, err := h.checkDuplicate(tx, comment.ID, req.Content)
+		if err != nil {
+			return err
+		}
+		hold := held && !comment.Held
+		if hold {
+			updates["held"] = true
+		}
+
+		result := tx.Model(&comment).Where("version = ?", comment.Version).Updates(updates)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errVersionConflict
+		}
+		if hold {
+			return tx.Model(&models.Post{}).Where("id = ?", comment.PostID).
+				UpdateColumn("comment_count", gorm.Expr("comment_count - ?", 1)).Error
+		}
+		return nil
+	}); err != nil {
+		if err == errVersionConflict {
+			h.respondCommentConflict(c, comment.ID)
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to update comment",
 		})
@@ -180,6 +237,7 @@ func (h *CommentHandler) UpdateComment(c *gin.Context) {
 		return
 	}
 
+	c.Header("ETag", versionETag(comment.Version))
 	c.JSON(http.StatusOK, gin.H{
 		"comment": comment,
 	})
@@ -203,22 +261,194 @@ func (h *CommentHandler) DeleteComment(c *gin.Context) {
 		return
 	}
 
-	result := h.db.Where("id = ? AND user_id = ?", commentUUID, userID).Delete(&models.Comment{})
-	if result.Error != nil {
+	var comment models.Comment
+	if err := h.db.Select("id", "post_id", "held", "version").
+		Where("id = ? AND user_id = ?", commentUUID, userID).
+		First(&comment).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Comment not found or not authorized",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch comment",
+			})
+		}
+		return
+	}
+
+	if !checkIfMatch(c, comment.Version) {
+		h.respondCommentConflict(c, comment.ID)
+		return
+	}
+
+	// Deleting the loaded comment, rather than by condition alone, lets its
+	// AfterDelete hook see the post and keep comment_count accurate. The hook
+	// runs even when the version no longer matches, so a lost race rolls the
+	// transaction back to undo it.
+	if err := h.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("version = ?", comment.Version).Delete(&comment)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errVersionConflict
+		}
+		return nil
+	}); err != nil {
+		if err == errVersionConflict {
+			h.respondCommentConflict(c, comment.ID)
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to delete comment",
 		})
 		return
 	}
 
-	if result.RowsAffected == 0 {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": "Comment not found or not authorized",
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Comment moved to trash",
+	})
+}
+
+// GetHeldComments lists the comments waiting for moderation, oldest first,
+// with the comment each one was found to duplicate.
+func (h *CommentHandler) GetHeldComments(c *gin.Context) {
+	var comments []models.Comment
+	if err := h.db.Preload("User").
+		Preload("Post", func(db *gorm.DB) *gorm.DB { return db.Select("id", "title", "slug", "locale") }).
+		Where("held = ?", true).
+		Order("created_at ASC").
+		Limit(100).
+		Find(&comments).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch held comments",
 		})
 		return
 	}
 
+	ids := make([]uuid.UUID, 0, len(comments))
+	for _, comment := range comments {
+		ids = append(ids, comment.ID)
+	}
+	var fingerprints []models.ContentFingerprint
+	if len(ids) > 0 {
+		h.db.Select("item_id", "duplicate_of_id", "similarity").
+			Where("kind = ? AND item_id IN ?", duplicates.KindComment, ids).
+			Find(&fingerprints)
+	}
+	matches := make(map[uuid.UUID]models.ContentFingerprint, len(fingerprints))
+	for _, fp := range fingerprints {
+		matches[fp.ItemID] = fp
+	}
+
+	held := make([]gin.H, 0, len(comments))
+	for _, comment := range comments {
+		entry := gin.H{"comment": comment}
+		if fp, ok := matches[comment.ID]; ok && fp.DuplicateOfID != nil {
+			entry["duplicate_of"] = fp.DuplicateOfID
+			entry["similarity"] = fp.Similarity
+		}
+		held = append(held, entry)
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Comment deleted successfully",
+		"comments": held,
 	})
 }
+
+// ApproveComment publishes a held comment.
+func (h *CommentHandler) ApproveComment(c *gin.Context) {
+	comment, ok := h.loadHeldComment(c)
+	if !ok {
+		return
+	}
+
+	if err := h.db.Transaction(func(tx *gorm.DB) error {
+		return comment.Approve(tx)
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to approve comment",
+		})
+		return
+	}
+
+	if err := h.db.Preload("User").First(comment, "id = ?", comment.ID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to load approved comment",
+		})
+		return
+	}
+
+	c.Header("ETag", versionETag(comment.Version))
+	c.JSON(http.StatusOK, gin.H{
+		"comment": comment,
+	})
+}
+
+// RejectComment moves a held comment to its author's trash.
+func (h *CommentHandler) RejectComment(c *gin.Context) {
+	comment, ok := h.loadHeldComment(c)
+	if !ok {
+		return
+	}
+
+	if err := h.db.Delete(comment).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to reject comment",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Comment rejected",
+	})
+}
+
+// checkDuplicate fingerprints a comment and reports whether it should be
+// held because it duplicates another one.
+func (h *CommentHandler) checkDuplicate(tx *gorm.DB, commentID uuid.UUID, content string) (bool, error) {
+	match, err := h.duplicates.Check(tx, duplicates.KindComment, commentID, content)
+	if err != nil {
+		return false, err
+	}
+	return match != nil && h.duplicates.Config().ModerateComments, nil
+}
+
+func (h *CommentHandler) loadHeldComment(c *gin.Context) (*models.Comment, bool) {
+	commentUUID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid comment ID",
+		})
+		return nil, false
+	}
+
+	var comment models.Comment
+	if err := h.db.Where("id = ? AND held = ?", commentUUID, true).First(&comment).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Held comment not found",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch comment",
+			})
+		}
+		return nil, false
+	}
+	return &comment, true
+}
+
+// respondCommentConflict answers a failed If-Match with the comment as it
+// is now.
+func (h *CommentHandler) respondCommentConflict(c *gin.Context, commentID uuid.UUID) {
+	var current models.Comment
+	if err := h.db.Preload("User").First(&current, "id = ?", commentID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Comment not found",
+		})
+		return
+	}
+	respondVersionConflict(c, "comment", current, current.Version)
+}
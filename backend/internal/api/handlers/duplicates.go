@@ -0,0 +1,237 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/yairfalse/modern-cloud-app/backend/internal/database/models"
+	"github.com/yairfalse/modern-cloud-app/backend/internal/duplicates"
+)
+
+// DuplicateHandler reports the near-duplicates found by the duplicates
+// package.
+type DuplicateHandler struct {
+	db *gorm.DB
+}
+
+type DuplicatesQuery struct {
+	Kind  string `form:"kind,default=post"`
+	Page  int    `form:"page,default=1"`
+	Limit int    `form:"limit,default=20"`
+}
+
+func NewDuplicateHandler(db *gorm.DB) *DuplicateHandler {
+	return &DuplicateHandler{db: db}
+}
+
+// GetDuplicateClusters groups posts or comments that were matched as
+// near-duplicates of one another, largest groups first.
+func (h *DuplicateHandler) GetDuplicateClusters(c *gin.Context) {
+	var query DuplicatesQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid query parameters",
+		})
+		return
+	}
+	kind := duplicates.Kind(query.Kind)
+	if !kind.Valid() {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid kind, must be post or comment",
+		})
+		return
+	}
+	if query.Page < 1 {
+		query.Page = 1
+	}
+	if query.Limit < 1 || query.Limit > 100 {
+		query.Limit = 20
+	}
+
+	var matches []models.ContentFingerprint
+	if err := h.db.Select("item_id", "duplicate_of_id", "similarity").
+		Where("kind = ? AND duplicate_of_id IS NOT NULL", kind).
+		Find(&matches).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch duplicates",
+		})
+		return
+	}
+
+	clusters := clusterMatches(matches)
+	total := len(clusters)
+	start := min((query.Page-1)*query.Limit, total)
+	clusters = clusters[start:min(start+query.Limit, total)]
+
+	var ids []uuid.UUID
+	for _, cluster := range clusters {
+		ids = append(ids, cluster...)
+	}
+	items, err := h.loadItems(kind, ids)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch duplicates",
+		})
+		return
+	}
+
+	edges := make(map[uuid.UUID]models.ContentFingerprint, len(matches))
+	for _, m := range matches {
+		edges[m.ItemID] = m
+	}
+
+	result := make([]gin.H, 0, len(clusters))
+	for _, cluster := range clusters {
+		members := make([]gin.H, 0, len(cluster))
+		for _, id := range cluster {
+			item, ok := items[id]
+			if !ok {
+				continue
+			}
+			if edge, ok := edges[id]; ok {
+				item["duplicate_of"] = edge.DuplicateOfID
+				item["similarity"] = edge.Similarity
+			}
+			members = append(members, item)
+		}
+		result = append(result, gin.H{
+			"size":  len(cluster),
+			"items": members,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"kind":     kind,
+		"clusters": result,
+		"pagination": gin.H{
+			"page":  query.Page,
+			"limit": query.Limit,
+			"total": total,
+			"pages": (total + query.Limit - 1) / query.Limit,
+		},
+	})
+}
+
+// loadItems returns a summary of each post or comment, including deleted
+// ones, keyed by ID.
+func (h *DuplicateHandler) loadItems(kind duplicates.Kind, ids []uuid.UUID) (map[uuid.UUID]gin.H, error) {
+	items := make(map[uuid.UUID]gin.H, len(ids))
+	if len(ids) == 0 {
+		return items, nil
+	}
+
+	if kind == duplicates.KindPost {
+		var posts []models.Post
+		if err := h.db.Unscoped().
+			Select("id", "title", "slug", "locale", "status", "author_id", "created_at", "deleted_at").
+			Where("id IN ?", ids).
+			Find(&posts).Error; err != nil {
+			return nil, err
+		}
+		for _, p := range posts {
+			items[p.ID] = gin.H{
+				"id":         p.ID,
+				"title":      p.Title,
+				"slug":       p.Slug,
+				"locale":     p.Locale,
+				"status":     p.Status,
+				"author_id":  p.AuthorID,
+				"created_at": p.CreatedAt,
+				"deleted":    p.DeletedAt.Valid,
+			}
+		}
+		return items, nil
+	}
+
+	var comments []models.Comment
+	if err := h.db.Unscoped().
+		Select("id", "post_id", "user_id", "content", "held", "created_at", "deleted_at").
+		Where("id IN ?", ids).
+		Find(&comments).Error; err != nil {
+		return nil, err
+	}
+	for _, comment := range comments {
+		items[comment.ID] = gin.H{
+			"id":         comment.ID,
+			"post_id":    comment.PostID,
+			"user_id":    comment.UserID,
+			"content":    comment.Content,
+			"held":       comment.Held,
+			"created_at": comment.CreatedAt,
+			"deleted":    comment.DeletedAt.Valid,
+		}
+	}
+	return items, nil
+}
+
+// clusterMatches joins items linked by a match into connected groups. Groups
+// are ordered by size, then by their smallest ID so pages are stable.
+func clusterMatches(matches []models.ContentFingerprint) [][]uuid.UUID {
+	parent := make(map[uuid.UUID]uuid.UUID)
+	var find func(uuid.UUID) uuid.UUID
+	find = func(id uuid.UUID) uuid.UUID {
+		p, ok := parent[id]
+		if !ok {
+			parent[id] = id
+			return id
+		}
+		if p == id {
+			return id
+		}
+		root := find(p)
+		parent[id] = root
+		return root
+	}
+	for _, m := range matches {
+		a, b := find(m.ItemID), find(*m.DuplicateOfID)
+		if a != b {
+			parent[a] = b
+		}
+	}
+
+	groups := make(map[uuid.UUID][]uuid.UUID)
+	for id := range parent {
+		root := find(id)
+		groups[root] = append(groups[root], id)
+	}
+
+	clusters := make([][]uuid.UUID, 0, len(groups))
+	for _, group := range groups {
+		sort.Slice(group, func(i, j int) bool { return group[i].String() < group[j].String() })
+		clusters = append(clusters, group)
+	}
+	sort.Slice(clusters, func(i, j int) bool {
+		if len(clusters[i]) != len(clusters[j]) {
+			return len(clusters[i]) > len(clusters[j])
+		}
+		return clusters[i][0].String() < clusters[j][0].String()
+	})
+	return clusters
+}
+
+// duplicateWarning tells an author that their new post closely matches an
+// existing one. The other post is only named when it is public, so drafts
+// of other authors are not revealed.
+func duplicateWarning(db *gorm.DB, match *duplicates.Match) gin.H {
+	warning := gin.H{
+		"type":       "duplicate",
+		"message":    "This post is very similar to an existing post",
+		"similarity": match.Similarity,
+	}
+	var existing models.Post
+	if err := db.Scopes(listedPosts).Select("id", "title", "slug", "locale").
+		Where("posts.id = ? AND posts.status = ?", match.ItemID, models.PostStatusPublished).
+		Take(&existing).Error; err == nil {
+		warning["post"] = gin.H{
+			"id":     existing.ID,
+			"title":  existing.Title,
+			"slug":   existing.Slug,
+			"locale": existing.Locale,
+		}
+	}
+	return warning
+}
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// errVersionConflict aborts a write whose If-Match version is no longer
+// current.
+var errVersionConflict = errors.New("version conflict")
+
+// versionETag formats a row version as a strong entity tag.
+func versionETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// ifMatchVersions parses the If-Match header into the versions it accepts.
+// It returns nil when the header is absent or "*", meaning any version is
+// accepted. Weak tags never match, as required for If-Match.
+func ifMatchVersions(c *gin.Context) map[int]bool {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" || header == "*" {
+		return nil
+	}
+
+	versions := make(map[int]bool)
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if strings.HasPrefix(tag, "W/") {
+			continue
+		}
+		if version, err := strconv.Atoi(strings.Trim(tag, `"`)); err == nil {
+			versions[version] = true
+		}
+	}
+	return versions
+}
+
+// checkIfMatch reports whether the request may modify a row at version.
+func checkIfMatch(c *gin.Context, version int) bool {
+	versions := ifMatchVersions(c)
+	return versions == nil || versions[version]
+}
+
+// respondVersionConflict answers a failed precondition with the current
+// state of the resource so the client can merge and retry.
+func respondVersionConflict(c *gin.Context, key string, current interface{}, version int) {
+	c.Header("ETag", versionETag(version))
+	c.JSON(http.StatusPreconditionFailed, gin.H{
+		"error":   "Resource has been modified since it was fetched",
+		"version": version,
+		key:       current,
+	})
+}
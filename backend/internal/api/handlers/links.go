@@ -0,0 +1,241 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/yairfalse/modern-cloud-app/backend/internal/database/models"
+)
+
+// LinkHandler serves the internal link graph kept by the linkgraph package.
+type LinkHandler struct {
+	db *gorm.DB
+}
+
+type LinkReportQuery struct {
+	Page  int    `form:"page,default=1"`
+	Limit int    `form:"limit,default=20"`
+	Issue string `form:"issue"`
+}
+
+// linkIssue is a reason a link needs fixing. The conditions are evaluated
+// against post_links joined with its target as "target", in order, so each
+// link is reported under its first matching issue.
+type linkIssue struct {
+	name      string
+	condition string
+}
+
+var linkIssues = []linkIssue{
+	{"missing", "target.id IS NULL"},
+	{"deleted", "target.deleted_at IS NOT NULL"},
+	{"unpublished", "target.deleted_at IS NULL AND target.status <> @published"},
+	{"renamed", "target.deleted_at IS NULL AND target.status = @published AND post_links.target_slug <> '' AND post_links.target_slug <> target.slug"},
+}
+
+func NewLinkHandler(db *gorm.DB) *LinkHandler {
+	return &LinkHandler{db: db}
+}
+
+// GetBacklinks lists the published posts that link to a published post.
+func (h *LinkHandler) GetBacklinks(c *gin.Context) {
+	postUUID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid post ID",
+		})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit < 1 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	var post models.Post
+	if err := h.db.Select("id").
+		Where("id = ? AND status = ? AND visibility <> ?", postUUID, models.PostStatusPublished, models.PostVisibilityPrivate).
+		First(&post).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Post not found",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch post",
+			})
+		}
+		return
+	}
+
+	sources := h.db.Model(&models.PostLink{}).Select("source_post_id").Where("target_post_id = ?", post.ID)
+
+	var posts []models.Post
+	if err := h.db.Scopes(listedPosts).
+		Where("posts.id IN (?) AND posts.id <> ? AND posts.status = ?", sources, post.ID, models.PostStatusPublished).
+		Order("posts.published_at DESC").
+		Limit(limit).
+		Find(&posts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch backlinks",
+		})
+		return
+	}
+
+	listed := make([]*models.Post, 0, len(posts))
+	for i := range posts {
+		listed = append(listed, &posts[i])
+	}
+	redactListed(c, listed)
+
+	backlinks := make([]gin.H, 0, len(posts))
+	for _, p := range posts {
+		backlinks = append(backlinks, gin.H{
+			"id":             p.ID,
+			"title":          p.Title,
+			"slug":           p.Slug,
+			"locale":         p.Locale,
+			"excerpt":        p.Excerpt,
+			"featured_image": p.FeaturedImage,
+			"published_at":   p.PublishedAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"backlinks": backlinks,
+	})
+}
+
+// GetLinkReport lists links in live posts that point at posts which are
+// missing, deleted, unpublished or have been renamed since the link was
+// written. Renamed links still resolve through the slug history, but should
+// be updated to the current slug.
+func (h *LinkHandler) GetLinkReport(c *gin.Context) {
+	var query LinkReportQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid query parameters",
+		})
+		return
+	}
+	if query.Page < 1 {
+		query.Page = 1
+	}
+	if query.Limit < 1 || query.Limit > 100 {
+		query.Limit = 20
+	}
+
+	issues := linkIssues
+	if query.Issue != "" {
+		issues = nil
+		for _, issue := range linkIssues {
+			if issue.name == query.Issue {
+				issues = []linkIssue{issue}
+			}
+		}
+		if issues == nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid issue, must be one of missing, deleted, unpublished or renamed",
+			})
+			return
+		}
+	}
+
+	args := map[string]interface{}{"published": models.PostStatusPublished}
+	matches := h.db.Where(issues[0].condition, args)
+	for _, issue := range issues[1:] {
+		matches = matches.Or(issue.condition, args)
+	}
+
+	db := h.db.Model(&models.PostLink{}).
+		Joins("JOIN posts AS source ON source.id = post_links.source_post_id AND source.deleted_at IS NULL").
+		Joins("LEFT JOIN posts AS target ON target.id = post_links.target_post_id").
+		Where(matches)
+
+	var total int64
+	if err := db.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch link report",
+		})
+		return
+	}
+
+	var links []models.PostLink
+	if err := db.Select("post_links.*").
+		Preload("Source", func(db *gorm.DB) *gorm.DB {
+			return db.Select("id", "title", "slug", "locale", "status")
+		}).
+		Preload("Target", func(db *gorm.DB) *gorm.DB {
+			return db.Unscoped().Select("id", "title", "slug", "locale", "status", "deleted_at")
+		}).
+		Order("post_links.created_at DESC").
+		Offset((query.Page - 1) * query.Limit).
+		Limit(query.Limit).
+		Find(&links).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch link report",
+		})
+		return
+	}
+
+	report := make([]gin.H, 0, len(links))
+	for _, link := range links {
+		entry := gin.H{
+			"id":          link.ID,
+			"href":        link.Href,
+			"issue":       issueOf(&link),
+			"target_slug": link.TargetSlug,
+		}
+		if link.Source != nil {
+			entry["source"] = gin.H{
+				"id":     link.Source.ID,
+				"title":  link.Source.Title,
+				"slug":   link.Source.Slug,
+				"locale": link.Source.Locale,
+				"status": link.Source.Status,
+			}
+		}
+		if link.Target != nil {
+			entry["target"] = gin.H{
+				"id":         link.Target.ID,
+				"title":      link.Target.Title,
+				"slug":       link.Target.Slug,
+				"locale":     link.Target.Locale,
+				"status":     link.Target.Status,
+				"deleted_at": link.Target.DeletedAt,
+			}
+		}
+		report = append(report, entry)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"links": report,
+		"pagination": gin.H{
+			"page":  query.Page,
+			"limit": query.Limit,
+			"total": total,
+			"pages": (total + int64(query.Limit) - 1) / int64(query.Limit),
+		},
+	})
+}
+
+// issueOf mirrors linkIssues for a link loaded with its target.
+func issueOf(link *models.PostLink) string {
+	switch target := link.Target; {
+	case target == nil:
+		return "missing"
+	case target.DeletedAt.Valid:
+		return "deleted"
+	case target.Status != models.PostStatusPublished:
+		return "unpublished"
+	default:
+		return "renamed"
+	}
+}
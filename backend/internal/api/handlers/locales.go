@@ -0,0 +1,346 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"golang.org/x/text/language"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/yairfalse/modern-cloud-app/backend/internal/api/middleware"
+	"github.com/yairfalse/modern-cloud-app/backend/internal/config"
+	"github.com/yairfalse/modern-cloud-app/backend/internal/database/models"
+)
+
+var (
+	errInvalidLocale       = errors.New("invalid locale")
+	errSameLocale          = errors.New("translations must be in different locales")
+	errTranslationConflict = errors.New("translation group already has a post in this locale")
+)
+
+// PostAlternate points at a published translation of a post, in the spirit of
+// an hreflang link. The default-locale version is repeated as "x-default".
+type PostAlternate struct {
+	Hreflang string    `json:"hreflang"`
+	Locale   string    `json:"locale"`
+	PostID   uuid.UUID `json:"post_id"`
+	Slug     string    `json:"slug"`
+	Href     string    `json:"href"`
+}
+
+type LinkTranslationRequest struct {
+	PostID uuid.UUID `json:"post_id" binding:"required"`
+}
+
+// localeMatcher negotiates Accept-Language against the configured locales.
+type localeMatcher struct {
+	config  config.I18nConfig
+	tags    []string
+	matcher language.Matcher
+}
+
+func newLocaleMatcher(cfg config.I18nConfig) *localeMatcher {
+	// The default locale goes first so the matcher falls back to it.
+	tags := []string{cfg.DefaultLocale}
+	for _, locale := range cfg.Locales {
+		if locale != cfg.DefaultLocale {
+			tags = append(tags, locale)
+		}
+	}
+
+	parsed := make([]language.Tag, len(tags))
+	for i, tag := range tags {
+		parsed[i] = language.Make(tag)
+	}
+
+	return &localeMatcher{
+		config:  cfg,
+		tags:    tags,
+		matcher: language.NewMatcher(parsed),
+	}
+}
+
+// preferred returns the reader's locale from the lang query parameter or the
+// Accept-Language header, and whether the reader asked for one at all.
+func (m *localeMatcher) preferred(c *gin.Context) (string, bool) {
+	if lang := c.Query("lang"); lang != "" && m.config.Supported(lang) {
+		return lang, true
+	}
+
+	header := c.GetHeader("Accept-Language")
+	if header == "" {
+		return m.config.DefaultLocale, false
+	}
+
+	accepted, _, err := language.ParseAcceptLanguage(header)
+	if err != nil || len(accepted) == 0 {
+		return m.config.DefaultLocale, false
+	}
+	_, index, confidence := m.matcher.Match(accepted...)
+	if confidence == language.No {
+		return m.config.DefaultLocale, false
+	}
+	return m.tags[index], true
+}
+
+// resolve returns the locale to store for a post, defaulting when empty.
+func (m *localeMatcher) resolve(locale string) (string, error) {
+	if locale == "" {
+		return m.config.DefaultLocale, nil
+	}
+	if !m.config.Supported(locale) {
+		return "", errInvalidLocale
+	}
+	return locale, nil
+}
+
+func (m *localeMatcher) respondInvalid(c *gin.Context) {
+	c.JSON(http.StatusBadRequest, gin.H{
+		"error": "Invalid locale, must be one of " + strings.Join(m.tags, ", "),
+	})
+}
+
+// byLocalePreference orders rows sharing a slug so the reader's locale comes
+// first, then the default locale. Use it with Take: First would replace the
+// ordering with the primary key.
+func (m *localeMatcher) byLocalePreference(table, locale string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Clauses(clause.OrderBy{Expression: clause.Expr{
+			SQL:                "CASE WHEN " + table + ".locale = ? THEN 0 WHEN " + table + ".locale = ? THEN 1 ELSE 2 END",
+			Vars:               []interface{}{locale, m.config.DefaultLocale},
+			WithoutParentheses: true,
+		}})
+	}
+}
+
+// publicTranslations limits a posts query to translations readers can reach.
+func publicTranslations(groupID uuid.UUID) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("posts.translation_group_id = ? AND posts.status = ? AND posts.visibility <> ?",
+			groupID, models.PostStatusPublished, models.PostVisibilityPrivate)
+	}
+}
+
+// alternates lists the published translations of post, including post itself.
+func (m *localeMatcher) alternates(db *gorm.DB, post *models.Post) []PostAlternate {
+	if post.TranslationGroupID == nil {
+		return []PostAlternate{}
+	}
+
+	var posts []models.Post
+	if err := db.Select("id", "slug", "locale").
+		Scopes(publicTranslations(*post.TranslationGroupID)).
+		Order("posts.locale ASC").
+		Find(&posts).Error; err != nil {
+		return []PostAlternate{}
+	}
+
+	alternates := make([]PostAlternate, 0, len(posts)+1)
+	for _, translation := range posts {
+		alternate := PostAlternate{
+			Hreflang: translation.Locale,
+			Locale:   translation.Locale,
+			PostID:   translation.ID,
+			Slug:     translation.Slug,
+			Href:     "/api/v1/posts/" + url.PathEscape(translation.Slug) + "?lang=" + url.QueryEscape(translation.Locale),
+		}
+		alternates = append(alternates, alternate)
+		if translation.Locale == m.config.DefaultLocale {
+			alternate.Hreflang = "x-default"
+			alternates = append(alternates, alternate)
+		}
+	}
+	return alternates
+}
+
+// LinkTranslation puts another post into the same translation group as this
+// one. The caller must be able to edit both posts.
+func (h *PostHandler) LinkTranslation(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	postUUID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid post ID",
+		})
+		return
+	}
+
+	var req LinkTranslationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	var posts []models.Post
+	if err := h.db.Scopes(canEditPost(userID)).
+		Where("posts.id IN ?", []uuid.UUID{postUUID, req.PostID}).
+		Find(&posts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch posts",
+		})
+		return
+	}
+	if len(posts) != 2 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Post not found or not authorized",
+		})
+		return
+	}
+
+	source, translation := &posts[0], &posts[1]
+	if source.ID != postUUID {
+		source, translation = translation, source
+	}
+
+	if err := h.db.Transaction(func(tx *gorm.DB) error {
+		return linkTranslation(tx, source, translation)
+	}); err != nil {
+		switch err {
+		case errSameLocale, errTranslationConflict:
+			c.JSON(http.StatusConflict, gin.H{
+				"error": err.Error(),
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to link translation",
+			})
+		}
+		return
+	}
+
+	var translations []models.Post
+	h.db.Where("translation_group_id = ?", source.TranslationGroupID).
+		Order("locale ASC").
+		Find(&translations)
+
+	c.JSON(http.StatusOK, gin.H{
+		"translation_group_id": source.TranslationGroupID,
+		"translations":         translations,
+	})
+}
+
+// UnlinkTranslation removes a post from its translation group. A group left
+// with a single post is dissolved.
+func (h *PostHandler) UnlinkTranslation(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	postUUID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid post ID",
+		})
+		return
+	}
+
+	var post models.Post
+	if err := h.db.Scopes(canEditPost(userID)).Where("posts.id = ?", postUUID).First(&post).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Post not found or not authorized",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch post",
+			})
+		}
+		return
+	}
+
+	if post.TranslationGroupID == nil {
+		c.JSON(http.StatusOK, gin.H{
+			"message": "Post is not linked to any translations",
+		})
+		return
+	}
+
+	if err := h.db.Transaction(func(tx *gorm.DB) error {
+		return leaveTranslationGroup(tx, &post)
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to unlink translation",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Translation unlinked successfully",
+	})
+}
+
+// linkTranslation moves translation into source's group, starting a new group
+// when source has none.
+func linkTranslation(tx *gorm.DB, source, translation *models.Post) error {
+	if source.Locale == translation.Locale {
+		return errSameLocale
+	}
+	if source.TranslationGroupID != nil && translation.TranslationGroupID != nil &&
+		*source.TranslationGroupID == *translation.TranslationGroupID {
+		return nil
+	}
+
+	groupID := uuid.New()
+	if source.TranslationGroupID != nil {
+		groupID = *source.TranslationGroupID
+
+		var taken int64
+		if err := tx.Model(&models.Post{}).
+			Where("translation_group_id = ? AND locale = ?", groupID, translation.Locale).
+			Count(&taken).Error; err != nil {
+			return err
+		}
+		if taken > 0 {
+			return errTranslationConflict
+		}
+	} else if err := tx.Model(source).Update("translation_group_id", groupID).Error; err != nil {
+		return err
+	}
+
+	if translation.TranslationGroupID != nil {
+		if err := leaveTranslationGroup(tx, translation); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Model(translation).Update("translation_group_id", groupID).Error; err != nil {
+		return err
+	}
+	source.TranslationGroupID = &groupID
+	return nil
+}
+
+func leaveTranslationGroup(tx *gorm.DB, post *models.Post) error {
+	groupID := *post.TranslationGroupID
+	if err := tx.Model(post).Update("translation_group_id", nil).Error; err != nil {
+		return err
+	}
+
+	var remaining []models.Post
+	if err := tx.Select("id").Where("translation_group_id = ?", groupID).Find(&remaining).Error; err != nil {
+		return err
+	}
+	if len(remaining) == 1 {
+		return tx.Model(&remaining[0]).Update("translation_group_id", nil).Error
+	}
+	return nil
+}
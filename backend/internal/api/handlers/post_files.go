@@ -0,0 +1,843 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/yairfalse/modern-cloud-app/backend/internal/annotations"
+	"github.com/yairfalse/modern-cloud-app/backend/internal/api/middleware"
+	"github.com/yairfalse/modern-cloud-app/backend/internal/database/models"
+	"github.com/yairfalse/modern-cloud-app/backend/internal/duplicates"
+	"github.com/yairfalse/modern-cloud-app/backend/internal/linkgraph"
+	"github.com/yairfalse/modern-cloud-app/backend/pkg/frontmatter"
+)
+
+const (
+	maxImportSize  = 10 << 20
+	maxImportFiles = 500
+)
+
+// PostFrontMatter is the YAML front matter of a post stored as a Markdown
+// file. Categories are referenced by slug so files can move between
+// installations. A missing visibility keeps that of an existing post. Password
+// is only read on import; exports leave it out, so a password-protected post
+// needs one added before it can be created elsewhere.
+type PostFrontMatter struct {
+	Title         string     `yaml:"title"`
+	Slug          string     `yaml:"slug,omitempty"`
+	Locale        string     `yaml:"locale,omitempty"`
+	Status        string     `yaml:"status,omitempty"`
+	Visibility    string     `yaml:"visibility,omitempty"`
+	Password      string     `yaml:"password,omitempty"`
+	Tags          []string   `yaml:"tags,omitempty"`
+	PublishedAt   *time.Time `yaml:"published_at,omitempty"`
+	ScheduledAt   *time.Time `yaml:"scheduled_at,omitempty"`
+	Excerpt       string     `yaml:"excerpt,omitempty"`
+	FeaturedImage string     `yaml:"featured_image,omitempty"`
+	Category      string     `yaml:"category,omitempty"`
+	Categories    []string   `yaml:"categories,omitempty"`
+
+	SEO *SEOFrontMatter `yaml:"seo,omitempty"`
+}
+
+// SEOFrontMatter holds a post's SEO overrides. When present it replaces all
+// of them, so a field left out is cleared; without it they are kept.
+type SEOFrontMatter struct {
+	MetaTitle       string `yaml:"meta_title,omitempty"`
+	MetaDescription string `yaml:"meta_description,omitempty"`
+	CanonicalURL    string `yaml:"canonical_url,omitempty"`
+	OGTitle         string `yaml:"og_title,omitempty"`
+	OGDescription   string `yaml:"og_description,omitempty"`
+	OGImage         string `yaml:"og_image,omitempty"`
+	TwitterCard     string `yaml:"twitter_card,omitempty"`
+	NoIndex         bool   `yaml:"noindex,omitempty"`
+}
+
+type ImportAction string
+
+const (
+	ImportActionCreate    ImportAction = "create"
+	ImportActionUpdate    ImportAction = "update"
+	ImportActionUnchanged ImportAction = "unchanged"
+	ImportActionError     ImportAction = "error"
+)
+
+type ImportResult struct {
+	File    string       `json:"file"`
+	Slug    string       `json:"slug,omitempty"`
+	PostID  *uuid.UUID   `json:"post_id,omitempty"`
+	Action  ImportAction `json:"action"`
+	Changes []string     `json:"changes,omitempty"`
+	Error   string       `json:"error,omitempty"`
+}
+
+type importFile struct {
+	name string
+	data []byte
+}
+
+// ImportPosts upserts posts by slug from uploaded Markdown files or ZIP
+// archives of them. With dry_run=true nothing is written and the response
+// lists what would change.
+func (h *PostHandler) ImportPosts(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	dryRun, _ := strconv.ParseBool(c.Query("dry_run"))
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportSize)
+	form, err := c.MultipartForm()
+	if err != nil || len(form.File["file"]) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Upload one or more files in the \"file\" field",
+		})
+		return
+	}
+
+	var results []ImportResult
+	var files []importFile
+	for _, upload := range form.File["file"] {
+		f, err := upload.Open()
+		if err != nil {
+			results = append(results, importError(upload.Filename, "", err))
+			continue
+		}
+		data, err := io.ReadAll(f)
+		f.Close()
+		if err != nil {
+			results = append(results, importError(upload.Filename, "", err))
+			continue
+		}
+
+		switch strings.ToLower(path.Ext(upload.Filename)) {
+		case ".zip":
+			entries, err := readImportArchive(upload.Filename, data)
+			if err != nil {
+				results = append(results, importError(upload.Filename, "", err))
+				continue
+			}
+			files = append(files, entries...)
+		case ".md", ".markdown":
+			files = append(files, importFile{name: upload.Filename, data: data})
+		default:
+			results = append(results, importError(upload.Filename, "", errors.New("unsupported file type, expected .md or .zip")))
+		}
+	}
+
+	if len(files) > maxImportFiles {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("Too many files, at most %d per import", maxImportFiles),
+		})
+		return
+	}
+
+	role, ok := middleware.GetUserRole(c, h.db)
+	if !ok {
+		respondRoleError(c)
+		return
+	}
+	for _, file := range files {
+		results = append(results, h.importPost(userID, role, file, dryRun))
+	}
+
+	summary := make(map[ImportAction]int)
+	for _, result := range results {
+		summary[result.Action]++
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"dry_run": dryRun,
+		"results": results,
+		"summary": summary,
+	})
+}
+
+// ExportPost returns a single post as a Markdown file with front matter.
+func (h *PostHandler) ExportPost(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	postUUID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid post ID",
+		})
+		return
+	}
+
+	db := h.db.Scopes(preloadTaxonomy)
+	if !middleware.IsEditor(c, h.db) {
+		db = db.Scopes(canEditPost(userID))
+	}
+
+	var post models.Post
+	if err := db.Where("posts.id = ?", postUUID).First(&post).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Post not found or not authorized",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch post",
+			})
+		}
+		return
+	}
+
+	data, err := postFile(&post)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to export post",
+		})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", h.postFileName(&post)))
+	c.Data(http.StatusOK, "text/markdown; charset=utf-8", data)
+}
+
+// ExportPosts returns a ZIP of every post the caller can edit, or every post
+// for editors, optionally filtered by status.
+func (h *PostHandler) ExportPosts(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	db := h.db.Scopes(preloadTaxonomy)
+	if !middleware.IsEditor(c, h.db) {
+		db = db.Scopes(canEditPost(userID))
+	}
+	if status := c.Query("status"); status != "" {
+		if !models.PostStatus(status).Valid() {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid status",
+			})
+			return
+		}
+		db = db.Where("posts.status = ?", status)
+	}
+
+	var posts []models.Post
+	if err := db.Order("posts.created_at ASC").Find(&posts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch posts",
+		})
+		return
+	}
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for i := range posts {
+		data, err := postFile(&posts[i])
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to export posts",
+			})
+			return
+		}
+		w, err := archive.CreateHeader(&zip.FileHeader{
+			Name:     h.postFileName(&posts[i]),
+			Method:   zip.Deflate,
+			Modified: posts[i].UpdatedAt,
+		})
+		if err == nil {
+			_, err = w.Write(data)
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to export posts",
+			})
+			return
+		}
+	}
+	if err := archive.Close(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to export posts",
+		})
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="posts.zip"`)
+	c.Data(http.StatusOK, "application/zip", buf.Bytes())
+}
+
+func (h *PostHandler) importPost(userID uuid.UUID, role models.UserRole, file importFile, dryRun bool) ImportResult {
+	var meta PostFrontMatter
+	body, err := frontmatter.Parse(file.data, &meta)
+	if err != nil {
+		return importError(file.name, "", err)
+	}
+
+	meta.Title = strings.TrimSpace(meta.Title)
+	if meta.Title == "" {
+		return importError(file.name, "", errors.New("front matter must set a title"))
+	}
+
+	status := models.PostStatusDraft
+	if meta.Status != "" {
+		status = models.PostStatus(meta.Status)
+	}
+	if !status.Valid() {
+		return importError(file.name, "", fmt.Errorf("invalid status %q", meta.Status))
+	}
+
+	if meta.Visibility != "" && !models.PostVisibility(meta.Visibility).Valid() {
+		return importError(file.name, "", fmt.Errorf("invalid visibility %q", meta.Visibility))
+	}
+
+	locale, err := h.locales.resolve(meta.Locale)
+	if err != nil {
+		return importError(file.name, "", fmt.Errorf("invalid locale %q", meta.Locale))
+	}
+	meta.Locale = locale
+
+	slug := generateSlug(meta.Slug)
+	if meta.Slug == "" {
+		slug = generateSlug(meta.Title)
+	}
+	if slug == "" {
+		return importError(file.name, "", errInvalidSlug)
+	}
+
+	primary, secondaries, err := h.resolveCategorySlugs(meta.Category, meta.Categories)
+	if err != nil {
+		return importError(file.name, slug, err)
+	}
+
+	var post models.Post
+	err = h.db.Scopes(preloadTaxonomy).Where("locale = ? AND slug = ?", locale, slug).First(&post).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return importError(file.name, slug, err)
+	}
+
+	if err == gorm.ErrRecordNotFound {
+		taken, err := slugTaken(h.db, locale, slug, uuid.Nil)
+		if err != nil {
+			return importError(file.name, slug, err)
+		}
+		if taken {
+			return importError(file.name, slug, errSlugTaken)
+		}
+		if err := models.CheckStatusChange(h.db, role, &models.Post{Status: models.PostStatusDraft}, status); err != nil {
+			return importError(file.name, slug, err)
+		}
+		if _, err := scheduleFor("", status, meta.ScheduledAt, nil); err != nil {
+			return importError(file.name, slug, err)
+		}
+		access, err := visibilityUpdate(models.PostVisibilityPublic, false, meta.Visibility, meta.Password)
+		if err != nil {
+			return importError(file.name, slug, err)
+		}
+		if _, err := importedSEO(meta.SEO, models.PostSEO{}); err != nil {
+			return importError(file.name, slug, err)
+		}
+		if dryRun {
+			return ImportResult{File: file.name, Slug: slug, Action: ImportActionCreate}
+		}
+		return h.createImportedPost(userID, file.name, slug, meta, body, status, access, primary, secondaries)
+	}
+
+	var editable int64
+	h.db.Model(&models.Post{}).Scopes(canEditPost(userID)).Where("posts.id = ?", post.ID).Count(&editable)
+	if editable == 0 {
+		return importError(file.name, slug, errors.New("not authorized to edit this post"))
+	}
+
+	publishedAt := meta.PublishedAt
+	if publishedAt == nil && status == models.PostStatusPublished {
+		publishedAt = post.PublishedAt
+		if publishedAt == nil {
+			now := time.Now()
+			publishedAt = &now
+		}
+	}
+
+	updates := make(map[string]interface{})
+	var changes []string
+	if meta.Title != post.Title {
+		updates["title"] = meta.Title
+		changes = append(changes, "title")
+	}
+	if body != post.Content {
+		rendered := post
+		rendered.Content = body
+		if err := h.renderContent(&rendered); err != nil {
+			return importError(file.name, slug, err)
+		}
+		updates["content"] = rendered.Content
+		updates["content_html"] = rendered.ContentHTML
+		updates["content_hash"] = rendered.ContentHash
+		updates["toc"] = rendered.TOC
+		updates["word_count"] = rendered.WordCount
+		updates["reading_time"] = rendered.ReadingTime
+		if rendered.ExcerptGenerated {
+			updates["excerpt"] = rendered.Excerpt
+			updates["excerpt_generated"] = true
+		}
+		changes = append(changes, "content")
+	}
+	if status != post.Status {
+		updates["status"] = status
+		changes = append(changes, "status")
+	}
+	if !sameTime(publishedAt, post.PublishedAt) {
+		updates["published_at"] = publishedAt
+		changes = append(changes, "published_at")
+	}
+	scheduledAt, err := scheduleFor(post.Status, status, meta.ScheduledAt, post.ScheduledAt)
+	if err != nil {
+		return importError(file.name, slug, err)
+	}
+	if !sameTime(scheduledAt, post.ScheduledAt) {
+		updates["scheduled_at"] = scheduledAt
+		changes = append(changes, "scheduled_at")
+	}
+	switch {
+	case meta.Excerpt != "" && (meta.Excerpt != post.Excerpt || post.ExcerptGenerated):
+		updates["excerpt"] = meta.Excerpt
+		updates["excerpt_generated"] = false
+		changes = append(changes, "excerpt")
+	case meta.Excerpt == "" && post.Excerpt != "" && !post.ExcerptGenerated:
+		// The written excerpt was removed, so one is generated instead.
+		generated := post
+		generated.Content = body
+		generated.Excerpt = ""
+		if err := h.renderContent(&generated); err != nil {
+			return importError(file.name, slug, err)
+		}
+		updates["excerpt"] = generated.Excerpt
+		updates["excerpt_generated"] = true
+		changes = append(changes, "excerpt")
+	}
+	if meta.FeaturedImage != post.FeaturedImage {
+		updates["featured_image"] = meta.FeaturedImage
+		changes = append(changes, "featured_image")
+	}
+	// The password is only stored hashed, so a file repeating the current
+	// one is not a change.
+	password := meta.Password
+	if password != "" && post.CheckPassword(password) {
+		password = ""
+	}
+	access, err := visibilityUpdate(post.Visibility, post.PasswordHash != "", meta.Visibility, password)
+	if err != nil {
+		return importError(file.name, slug, err)
+	}
+	for column, value := range access {
+		updates[column] = value
+	}
+	if _, changed := access["visibility"]; changed {
+		changes = append(changes, "visibility")
+	} else if hash, _ := access["password_hash"].(string); hash != "" {
+		changes = append(changes, "password")
+	}
+	seo, err := importedSEO(meta.SEO, post.SEO)
+	if err != nil {
+		return importError(file.name, slug, err)
+	}
+	if seo != post.SEO {
+		for column, value := range seoColumns(seo) {
+			updates[column] = value
+		}
+		changes = append(changes, "seo")
+	}
+	if meta.Slug != "" && !post.SlugPinned {
+		updates["slug_pinned"] = true
+	}
+
+	var current uuid.UUID
+	if post.CategoryID != nil {
+		current = *post.CategoryID
+	}
+	categoryChanged := *primary != current
+	if categoryChanged {
+		changes = append(changes, "category")
+	}
+
+	currentSecondaries := make([]uuid.UUID, 0, len(post.SecondaryCategories))
+	for _, category := range post.SecondaryCategories {
+		currentSecondaries = append(currentSecondaries, category.ID)
+	}
+	secondariesChanged := !sameIDSet(secondaries, currentSecondaries)
+	if secondariesChanged {
+		changes = append(changes, "categories")
+	}
+
+	currentTags := make([]string, 0, len(post.Tags))
+	for _, tag := range post.Tags {
+		currentTags = append(currentTags, tag.Slug)
+	}
+	tagsChanged := !sameTagSet(meta.Tags, currentTags)
+	if tagsChanged {
+		changes = append(changes, "tags")
+	}
+
+	if status != post.Status {
+		if err := models.CheckStatusChange(h.db, role, &post, status); err != nil {
+			return importError(file.name, slug, err)
+		}
+		if role == models.UserRoleContributor &&
+			(status == models.PostStatusPublished || status == models.PostStatusScheduled) {
+			for _, change := range changes {
+				if change != "status" && change != "published_at" && change != "scheduled_at" {
+					return importError(file.name, slug, errPublishWithChanges)
+				}
+			}
+		}
+	}
+
+	result := ImportResult{File: file.name, Slug: slug, PostID: &post.ID, Action: ImportActionUpdate, Changes: changes}
+	if len(changes) == 0 {
+		result.Action = ImportActionUnchanged
+	}
+	if dryRun || len(changes) == 0 {
+		return result
+	}
+
+	if !categoryChanged {
+		primary = nil
+	}
+	if !secondariesChanged {
+		secondaries = nil
+	}
+
+	if err := h.db.Transaction(func(tx *gorm.DB) error {
+		if len(updates) > 0 {
+			if err := tx.Model(&post).Updates(updates).Error; err != nil {
+				return err
+			}
+			if content, changed := updates["content"].(string); changed {
+				if err := annotations.Reanchor(tx, post.ID, content, post.Version+1); err != nil {
+					return err
+				}
+				linked := post
+				linked.Content = content
+				if err := h.links.Update(tx, &linked); err != nil {
+					return err
+				}
+				if _, err := h.duplicates.Check(tx, duplicates.KindPost, post.ID, content); err != nil {
+					return err
+				}
+			}
+		}
+		return setPostCategories(tx, &post, primary, secondaries)
+	}); err != nil {
+		return importError(file.name, slug, err)
+	}
+
+	if tagsChanged {
+		if err := h.associateTags(&post, meta.Tags); err != nil {
+			return importError(file.name, slug, err)
+		}
+	}
+
+	h.related.Enqueue(post.ID)
+	return result
+}
+
+func (h *PostHandler) createImportedPost(userID uuid.UUID, name, slug string, meta PostFrontMatter, body string, status models.PostStatus, access map[string]interface{}, primary *uuid.UUID, secondaries []uuid.UUID) ImportResult {
+	post := models.Post{
+		Title:         meta.Title,
+		Slug:          slug,
+		Locale:        meta.Locale,
+		SlugPinned:    meta.Slug != "",
+		Content:       body,
+		Excerpt:       meta.Excerpt,
+		FeaturedImage: meta.FeaturedImage,
+		Status:        status,
+		Visibility:    models.PostVisibilityPublic,
+		AuthorID:      userID,
+		PublishedAt:   meta.PublishedAt,
+	}
+	if visibility, ok := access["visibility"].(models.PostVisibility); ok {
+		post.Visibility = visibility
+	}
+	if hash, ok := access["password_hash"].(string); ok {
+		post.PasswordHash = hash
+	}
+	scheduledAt, err := scheduleFor("", status, meta.ScheduledAt, nil)
+	if err != nil {
+		return importError(name, slug, err)
+	}
+	post.ScheduledAt = scheduledAt
+	if post.SEO, err = importedSEO(meta.SEO, models.PostSEO{}); err != nil {
+		return importError(name, slug, err)
+	}
+	if post.PublishedAt == nil && status == models.PostStatusPublished {
+		now := time.Now()
+		post.PublishedAt = &now
+	}
+
+	if err := h.renderContent(&post); err != nil {
+		return importError(name, slug, err)
+	}
+
+	if err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&post).Error; err != nil {
+			return err
+		}
+		now := time.Now().UTC()
+		if err := tx.Create(&models.PostCollaborator{
+			PostID:     post.ID,
+			UserID:     userID,
+			Role:       models.CollaboratorRoleOwner,
+			Status:     models.CollaboratorStatusAccepted,
+			AcceptedAt: &now,
+		}).Error; err != nil {
+			return err
+		}
+		if err := h.links.Update(tx, &post); err != nil {
+			return err
+		}
+		if err := linkgraph.Attach(tx, &post); err != nil {
+			return err
+		}
+		if _, err := h.duplicates.Check(tx, duplicates.KindPost, post.ID, post.Content); err != nil {
+			return err
+		}
+		return setPostCategories(tx, &post, primary, secondaries)
+	}); err != nil {
+		return importError(name, slug, err)
+	}
+
+	if len(meta.Tags) > 0 {
+		if err := h.associateTags(&post, meta.Tags); err != nil {
+			return importError(name, slug, err)
+		}
+	}
+
+	h.related.Enqueue(post.ID)
+	return ImportResult{File: name, Slug: slug, PostID: &post.ID, Action: ImportActionCreate}
+}
+
+// resolveCategorySlugs maps front matter category slugs to IDs. An empty
+// primary resolves to uuid.Nil so that setPostCategories clears it.
+func (h *PostHandler) resolveCategorySlugs(primary string, secondaries []string) (*uuid.UUID, []uuid.UUID, error) {
+	lookup := func(slug string) (uuid.UUID, error) {
+		var category models.Category
+		if err := h.db.Select("id").Where("slug = ?", slug).First(&category).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return uuid.Nil, fmt.Errorf("category %q not found", slug)
+			}
+			return uuid.Nil, err
+		}
+		return category.ID, nil
+	}
+
+	primaryID := uuid.Nil
+	if primary != "" {
+		id, err := lookup(primary)
+		if err != nil {
+			return nil, nil, err
+		}
+		primaryID = id
+	}
+
+	secondaryIDs := make([]uuid.UUID, 0, len(secondaries))
+	for _, slug := range secondaries {
+		id, err := lookup(slug)
+		if err != nil {
+			return nil, nil, err
+		}
+		secondaryIDs = append(secondaryIDs, id)
+	}
+
+	return &primaryID, uniqueIDs(secondaryIDs), nil
+}
+
+// postFileName names an exported post after its slug, adding the locale for
+// posts outside the default locale since slugs are only unique per locale.
+func (h *PostHandler) postFileName(post *models.Post) string {
+	if post.Locale == "" || post.Locale == h.locales.config.DefaultLocale {
+		return post.Slug + ".md"
+	}
+	return post.Slug + "." + post.Locale + ".md"
+}
+
+// postFile renders a post, with its taxonomy preloaded, as a Markdown file.
+// Importing the result reproduces the post unchanged.
+func postFile(post *models.Post) ([]byte, error) {
+	meta := PostFrontMatter{
+		Title:         post.Title,
+		Slug:          post.Slug,
+		Locale:        post.Locale,
+		Status:        string(post.Status),
+		Visibility:    string(post.Visibility),
+		FeaturedImage: post.FeaturedImage,
+	}
+	// Generated excerpts are left out so they keep following the content.
+	if !post.ExcerptGenerated {
+		meta.Excerpt = post.Excerpt
+	}
+
+	if post.PublishedAt != nil {
+		publishedAt := post.PublishedAt.UTC()
+		meta.PublishedAt = &publishedAt
+	}
+	if post.Status == models.PostStatusScheduled && post.ScheduledAt != nil {
+		scheduledAt := post.ScheduledAt.UTC()
+		meta.ScheduledAt = &scheduledAt
+	}
+
+	if seo := post.SEO; seo != (models.PostSEO{}) {
+		meta.SEO = &SEOFrontMatter{
+			MetaTitle:       seo.MetaTitle,
+			MetaDescription: seo.MetaDescription,
+			CanonicalURL:    seo.CanonicalURL,
+			OGTitle:         seo.OGTitle,
+			OGDescription:   seo.OGDescription,
+			OGImage:         seo.OGImage,
+			TwitterCard:     string(seo.TwitterCard),
+			NoIndex:         seo.NoIndex,
+		}
+	}
+
+	for _, tag := range post.Tags {
+		meta.Tags = append(meta.Tags, tag.Name)
+	}
+	sort.Strings(meta.Tags)
+
+	if post.Category != nil {
+		meta.Category = post.Category.Slug
+	}
+	for _, category := range post.SecondaryCategories {
+		meta.Categories = append(meta.Categories, category.Slug)
+	}
+	sort.Strings(meta.Categories)
+
+	return frontmatter.Format(meta, post.Content)
+}
+
+func readImportArchive(name string, data []byte) ([]importFile, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, errors.New("invalid ZIP archive")
+	}
+
+	var files []importFile
+	var total int64
+	for _, entry := range archive.File {
+		base := path.Base(entry.Name)
+		if entry.FileInfo().IsDir() || strings.HasPrefix(entry.Name, "__MACOSX/") || strings.HasPrefix(base, ".") {
+			continue
+		}
+		if ext := strings.ToLower(path.Ext(base)); ext != ".md" && ext != ".markdown" {
+			continue
+		}
+
+		// Bound the uncompressed size as well, whatever the header claims.
+		r, err := entry.Open()
+		if err != nil {
+			return nil, err
+		}
+		content, err := io.ReadAll(io.LimitReader(r, maxImportSize-total+1))
+		r.Close()
+		if err != nil {
+			return nil, err
+		}
+		total += int64(len(content))
+		if total > maxImportSize {
+			return nil, errors.New("ZIP archive is too large when extracted")
+		}
+
+		files = append(files, importFile{name: path.Join(name, entry.Name), data: content})
+	}
+	return files, nil
+}
+
+// importedSEO returns the SEO overrides an imported file gives a post whose
+// overrides are current.
+func importedSEO(meta *SEOFrontMatter, current models.PostSEO) (models.PostSEO, error) {
+	if meta == nil {
+		return current, nil
+	}
+	return seoUpdate(models.PostSEO{}, &SEORequest{
+		MetaTitle:       &meta.MetaTitle,
+		MetaDescription: &meta.MetaDescription,
+		CanonicalURL:    &meta.CanonicalURL,
+		OGTitle:         &meta.OGTitle,
+		OGDescription:   &meta.OGDescription,
+		OGImage:         &meta.OGImage,
+		TwitterCard:     &meta.TwitterCard,
+		NoIndex:         &meta.NoIndex,
+	})
+}
+
+func importError(name, slug string, err error) ImportResult {
+	return ImportResult{File: name, Slug: slug, Action: ImportActionError, Error: err.Error()}
+}
+
+// sameTime compares timestamps at the precision Postgres stores.
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return a.Truncate(time.Microsecond).Equal(b.Truncate(time.Microsecond))
+}
+
+func sameIDSet(a, b []uuid.UUID) bool {
+	a, b = uniqueIDs(a), uniqueIDs(b)
+	if len(a) != len(b) {
+		return false
+	}
+	seen := make(map[uuid.UUID]bool, len(a))
+	for _, id := range a {
+		seen[id] = true
+	}
+	for _, id := range b {
+		if !seen[id] {
+			return false
+		}
+	}
+	return true
+}
+
+// sameTagSet compares tag names from a file against stored tag slugs.
+func sameTagSet(names, slugs []string) bool {
+	want := make(map[string]bool, len(names))
+	for _, name := range names {
+		if slug := generateSlug(strings.TrimSpace(name)); slug != "" {
+			want[slug] = true
+		}
+	}
+	have := make(map[string]bool, len(slugs))
+	for _, slug := range slugs {
+		have[slug] = true
+	}
+	if len(want) != len(have) {
+		return false
+	}
+	for slug := range want {
+		if !have[slug] {
+			return false
+		}
+	}
+	return true
+}
@@ -2,49 +2,117 @@ package handlers
 
 import (
 	"net/http"
+	"net/url"
+	"path"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 
+	"github.com/yairfalse/modern-cloud-app/backend/internal/annotations"
 	"github.com/yairfalse/modern-cloud-app/backend/internal/api/middleware"
+	"github.com/yairfalse/modern-cloud-app/backend/internal/config"
 	"github.com/yairfalse/modern-cloud-app/backend/internal/database/models"
+	"github.com/yairfalse/modern-cloud-app/backend/internal/duplicates"
+	"github.com/yairfalse/modern-cloud-app/backend/internal/linkgraph"
+	"github.com/yairfalse/modern-cloud-app/backend/internal/related"
+	"github.com/yairfalse/modern-cloud-app/backend/internal/views"
+	"github.com/yairfalse/modern-cloud-app/backend/pkg/auth"
+	"github.com/yairfalse/modern-cloud-app/backend/pkg/markdown"
+	"github.com/yairfalse/modern-cloud-app/backend/pkg/slugify"
 )
 
 type PostHandler struct {
-	db *gorm.DB
+	db         *gorm.DB
+	renderer   *markdown.Renderer
+	jwtManager *auth.JWTManager
+	jwtConfig  config.JWTConfig
+	related    *related.Engine
+	views      *views.Counter
+	locales    *localeMatcher
+	links      *linkgraph.Graph
+	duplicates *duplicates.Detector
+	seo        config.SEOConfig
+	unlocks    *unlockLimiter
 }
 
 type CreatePostRequest struct {
 	Title         string   `json:"title" binding:"required,min=1,max=255"`
+	Slug          string   `json:"slug"`
 	Content       string   `json:"content" binding:"required"`
 	Excerpt       string   `json:"excerpt"`
 	FeaturedImage string   `json:"featured_image"`
 	Status        string   `json:"status"`
+	Locale        string   `json:"locale"`
+	Visibility    string   `json:"visibility"`
+	Password      string   `json:"password"`
 	Tags          []string `json:"tags"`
+
+	CategoryID           *uuid.UUID  `json:"category_id"`
+	SecondaryCategoryIDs []uuid.UUID `json:"secondary_category_ids"`
+
+	// ScheduledAt is when a post with status scheduled goes live.
+	ScheduledAt *time.Time  `json:"scheduled_at"`
+	SEO         *SEORequest `json:"seo"`
 }
 
 type UpdatePostRequest struct {
 	Title         string   `json:"title"`
+	Slug          string   `json:"slug"`
+	SlugPinned    *bool    `json:"slug_pinned"`
 	Content       string   `json:"content"`
 	Excerpt       string   `json:"excerpt"`
 	FeaturedImage string   `json:"featured_image"`
 	Status        string   `json:"status"`
+	Locale        string   `json:"locale"`
+	Visibility    string   `json:"visibility"`
+	Password      string   `json:"password"`
 	Tags          []string `json:"tags"`
+
+	CategoryID           *uuid.UUID  `json:"category_id"`
+	SecondaryCategoryIDs []uuid.UUID `json:"secondary_category_ids"`
+
+	// ScheduledAt is when a post with status scheduled goes live.
+	ScheduledAt *time.Time  `json:"scheduled_at"`
+	SEO         *SEORequest `json:"seo"`
 }
 
 type PostsQuery struct {
 	Page     int    `form:"page,default=1"`
 	Limit    int    `form:"limit,default=10"`
+	Cursor   string `form:"cursor"`
+	Sort     string `form:"sort,default=published"`
 	Status   string `form:"status"`
 	AuthorID string `form:"author_id"`
 	Tag      string `form:"tag"`
+	Category string `form:"category"`
+	Lang     string `form:"lang"`
 	Search   string `form:"search"`
 }
 
-func NewPostHandler(db *gorm.DB) *PostHandler {
-	return &PostHandler{db: db}
+type MyPostsQuery struct {
+	Page   int    `form:"page,default=1"`
+	Limit  int    `form:"limit,default=20"`
+	Status string `form:"status"`
+	Search string `form:"search"`
+}
+
+func NewPostHandler(db *gorm.DB, jwtManager *auth.JWTManager, jwtConfig config.JWTConfig, relatedEngine *related.Engine, viewCounter *views.Counter, i18nConfig config.I18nConfig, linkGraph *linkgraph.Graph, detector *duplicates.Detector, seoConfig config.SEOConfig) *PostHandler {
+	return &PostHandler{
+		db:         db,
+		renderer:   markdown.NewRenderer(),
+		jwtManager: jwtManager,
+		jwtConfig:  jwtConfig,
+		related:    relatedEngine,
+		views:      viewCounter,
+		locales:    newLocaleMatcher(i18nConfig),
+		links:      linkGraph,
+		duplicates: detector,
+		seo:        seoConfig,
+		unlocks:    newUnlockLimiter(jwtConfig.UnlockMaxAttempts, jwtConfig.UnlockLockout),
+	}
 }
 
 func (h *PostHandler) CreatePost(c *gin.Context) {
@@ -65,29 +133,111 @@ func (h *PostHandler) CreatePost(c *gin.Context) {
 		return
 	}
 
-	slug := generateSlug(req.Title)
+	locale, err := h.locales.resolve(req.Locale)
+	if err != nil {
+		h.locales.respondInvalid(c)
+		return
+	}
 
-	var existingPost models.Post
-	if err := h.db.Where("slug = ?", slug).First(&existingPost).Error; err == nil {
-		slug = slug + "-" + uuid.New().String()[:8]
+	slug, err := resolveSlug(h.db, locale, req.Title, req.Slug, uuid.Nil)
+	if err != nil {
+		respondSlugError(c, err)
+		return
 	}
 
 	status := models.PostStatusDraft
 	if req.Status != "" {
 		status = models.PostStatus(req.Status)
 	}
+	role, ok := middleware.GetUserRole(c, h.db)
+	if !ok {
+		respondRoleError(c)
+		return
+	}
+	if err := models.CheckStatusChange(h.db, role, &models.Post{Status: models.PostStatusDraft}, status); err != nil {
+		respondStatusError(c, err)
+		return
+	}
+	scheduledAt, err := scheduleFor("", status, req.ScheduledAt, nil)
+	if err != nil {
+		respondStatusError(c, err)
+		return
+	}
+
+	access, err := visibilityUpdate(models.PostVisibilityPublic, false, req.Visibility, req.Password)
+	if err != nil {
+		respondVisibilityError(c, err)
+		return
+	}
+
+	seo, err := seoUpdate(models.PostSEO{}, req.SEO)
+	if err != nil {
+		respondSEOError(c, err)
+		return
+	}
 
 	post := models.Post{
 		Title:         req.Title,
 		Slug:          slug,
+		Locale:        locale,
+		SlugPinned:    req.Slug != "",
 		Content:       req.Content,
 		Excerpt:       req.Excerpt,
 		FeaturedImage: req.FeaturedImage,
 		Status:        status,
+		ScheduledAt:   scheduledAt,
+		Visibility:    models.PostVisibilityPublic,
 		AuthorID:      userID,
+		SEO:           seo,
+	}
+	if visibility, ok := access["visibility"].(models.PostVisibility); ok {
+		post.Visibility = visibility
+	}
+	if hash, ok := access["password_hash"].(string); ok {
+		post.PasswordHash = hash
+	}
+
+	if err := h.renderContent(&post); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Failed to render content",
+		})
+		return
 	}
 
-	if err := h.db.Create(&post).Error; err != nil {
+	var duplicate *duplicates.Match
+	if err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&post).Error; err != nil {
+			return err
+		}
+		now := time.Now().UTC()
+		if err := tx.Create(&models.PostCollaborator{
+			PostID:     post.ID,
+			UserID:     userID,
+			Role:       models.CollaboratorRoleOwner,
+			Status:     models.CollaboratorStatusAccepted,
+			AcceptedAt: &now,
+		}).Error; err != nil {
+			return err
+		}
+		if err := h.links.Update(tx, &post); err != nil {
+			return err
+		}
+		if err := linkgraph.Attach(tx, &post); err != nil {
+			return err
+		}
+		match, err := h.duplicates.Check(tx, duplicates.KindPost, post.ID, post.Content)
+		if err != nil {
+			return err
+		}
+		duplicate = match
+		return setPostCategories(tx, &post, req.CategoryID, req.SecondaryCategoryIDs)
+	}); err != nil {
+		if err == errCategoryNotFound {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Category not found",
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to create post",
 		})
@@ -103,13 +253,23 @@ func (h *PostHandler) CreatePost(c *gin.Context) {
 		}
 	}
 
-	if err := h.db.Preload("Author").Preload("Tags").First(&post, post.ID).Error; err != nil {
+	if err := h.db.Scopes(preloadByline, preloadTaxonomy).First(&post, post.ID).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to load post",
 		})
 		return
 	}
 
+	h.related.Enqueue(post.ID)
+
+	c.Header("ETag", versionETag(post.Version))
+	if duplicate != nil && h.duplicates.Config().WarnPosts {
+		c.JSON(http.StatusCreated, gin.H{
+			"post":     post,
+			"warnings": []gin.H{duplicateWarning(h.db, duplicate)},
+		})
+		return
+	}
 	c.JSON(http.StatusCreated, gin.H{
 		"post": post,
 	})
@@ -127,22 +287,79 @@ func (h *PostHandler) GetPosts(c *gin.Context) {
 	if query.Limit > 50 {
 		query.Limit = 50
 	}
+	if query.Limit < 1 {
+		query.Limit = 10
+	}
+	if query.Page < 1 {
+		query.Page = 1
+	}
+
+	if _, ok := postSorts[query.Sort]; !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid sort, must be one of newest, oldest, published, popular, trending, discussed",
+		})
+		return
+	}
+
+	var cursor *postCursor
+	if query.Cursor != "" {
+		decoded, err := decodeCursor(query.Sort, query.Cursor)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid cursor",
+			})
+			return
+		}
+		cursor = decoded
+	}
 
 	offset := (query.Page - 1) * query.Limit
+	if cursor != nil {
+		offset = 0
+	}
 
 	db := h.db.Model(&models.Post{}).
-		Preload("Author").
-		Preload("Tags")
+		Scopes(preloadByline, preloadTaxonomy)
 
-	if query.Status != "" {
-		db = db.Where("status = ?", query.Status)
+	status := models.PostStatus(query.Status)
+	if status == "" {
+		status = models.PostStatusPublished
+	}
+	if !status.Valid() {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid status",
+		})
+		return
+	}
+	db = db.Where("posts.status = ?", status)
+
+	// Unpublished posts are only listed to people who can edit them.
+	if status == models.PostStatusPublished {
+		db = db.Scopes(listedPosts)
 	} else {
-		db = db.Where("status = ?", models.PostStatusPublished)
+		viewerID, ok := middleware.GetUserID(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "Authentication required to list unpublished posts",
+			})
+			return
+		}
+		if !middleware.IsEditor(c, h.db) {
+			db = db.Scopes(canEditPost(viewerID))
+		}
+	}
+
+	if query.Lang != "" {
+		if !h.locales.config.Supported(query.Lang) {
+			h.locales.respondInvalid(c)
+			return
+		}
+		db = db.Where("posts.locale = ?", query.Lang)
 	}
 
 	if query.AuthorID != "" {
 		if authorUUID, err := uuid.Parse(query.AuthorID); err == nil {
-			db = db.Where("author_id = ?", authorUUID)
+			db = db.Scopes(bylinedBy(authorUUID))
 		}
 	}
 
@@ -157,13 +374,17 @@ func (h *PostHandler) GetPosts(c *gin.Context) {
 			Where("tags.slug = ?", query.Tag)
 	}
 
+	if query.Category != "" {
+		db = db.Scopes(inCategory(query.Category))
+	}
+
 	var total int64
 	db.Count(&total)
 
 	var posts []models.Post
-	if err := db.Order("created_at DESC").
+	if err := orderPosts(db, query.Sort, cursor).
 		Offset(offset).
-		Limit(query.Limit).
+		Limit(query.Limit + 1).
 		Find(&posts).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to fetch posts",
@@ -171,8 +392,125 @@ func (h *PostHandler) GetPosts(c *gin.Context) {
 		return
 	}
 
+	var nextCursor string
+	if len(posts) > query.Limit {
+		posts = posts[:query.Limit]
+		nextCursor = encodeCursor(query.Sort, &posts[len(posts)-1])
+	}
+
+	viewerPosts := make([]*models.Post, len(posts))
+	for i := range posts {
+		h.ensureRendered(&posts[i])
+		viewerPosts[i] = &posts[i]
+	}
+	if viewerID, ok := middleware.GetUserID(c); ok {
+		markViewerState(h.db, viewerID, viewerPosts)
+	}
+	if status == models.PostStatusPublished {
+		redactListed(c, viewerPosts)
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"posts": posts,
+		"pagination": gin.H{
+			"page":        query.Page,
+			"limit":       query.Limit,
+			"total":       total,
+			"pages":       (total + int64(query.Limit) - 1) / int64(query.Limit),
+			"sort":        query.Sort,
+			"next_cursor": nextCursor,
+		},
+	})
+}
+
+// GetMyPosts lists every post the caller can edit, in any status, along with
+// how many of them are in each status.
+func (h *PostHandler) GetMyPosts(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	var query MyPostsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid query parameters",
+		})
+		return
+	}
+	if query.Limit > 100 {
+		query.Limit = 100
+	}
+	if query.Limit < 1 {
+		query.Limit = 20
+	}
+	if query.Page < 1 {
+		query.Page = 1
+	}
+
+	var rows []struct {
+		Status models.PostStatus
+		Count  int64
+	}
+	if err := h.db.Model(&models.Post{}).
+		Scopes(canEditPost(userID)).
+		Select("posts.status, COUNT(*) AS count").
+		Group("posts.status").
+		Scan(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to count posts",
+		})
+		return
+	}
+	counts := gin.H{
+		string(models.PostStatusDraft):     int64(0),
+		string(models.PostStatusInReview):  int64(0),
+		string(models.PostStatusScheduled): int64(0),
+		string(models.PostStatusPublished): int64(0),
+		string(models.PostStatusArchived):  int64(0),
+	}
+	for _, row := range rows {
+		counts[string(row.Status)] = row.Count
+	}
+
+	db := h.db.Model(&models.Post{}).
+		Scopes(canEditPost(userID), preloadByline, preloadTaxonomy)
+
+	if query.Status != "" {
+		if !models.PostStatus(query.Status).Valid() {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid status",
+			})
+			return
+		}
+		db = db.Where("posts.status = ?", query.Status)
+	}
+
+	if query.Search != "" {
+		searchTerm := "%" + query.Search + "%"
+		db = db.Where("title ILIKE ? OR content ILIKE ?", searchTerm, searchTerm)
+	}
+
+	var total int64
+	db.Count(&total)
+
+	var posts []models.Post
+	if err := db.Order("posts.updated_at DESC").
+		Offset((query.Page - 1) * query.Limit).
+		Limit(query.Limit).
+		Find(&posts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch posts",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"posts":  posts,
+		"counts": counts,
 		"pagination": gin.H{
 			"page":  query.Page,
 			"limit": query.Limit,
@@ -182,20 +520,53 @@ func (h *PostHandler) GetPosts(c *gin.Context) {
 	})
 }
 
+// GetPost looks a post up by ID or slug. Slugs are only unique per locale, so
+// a slug resolves to the reader's locale first and then the default locale,
+// and a reader who asked for a locale is given the translation in it when
+// there is one.
 func (h *PostHandler) GetPost(c *gin.Context) {
 	id := c.Param("id")
+	locale, negotiated := h.locales.preferred(c)
 
 	var post models.Post
 	var err error
 
-	if uuid, parseErr := uuid.Parse(id); parseErr == nil {
-		err = h.db.Preload("Author").Preload("Tags").Preload("Comments.User").
-			Where("id = ? AND status = ?", uuid, models.PostStatusPublished).
+	postUUID, parseErr := uuid.Parse(id)
+	if parseErr == nil {
+		err = h.db.Scopes(preloadByline, preloadTaxonomy).Scopes(preloadComments).
+			Where("id = ? AND status = ?", postUUID, models.PostStatusPublished).
 			First(&post).Error
 	} else {
-		err = h.db.Preload("Author").Preload("Tags").Preload("Comments.User").
+		err = h.db.Scopes(preloadByline, preloadTaxonomy, h.locales.byLocalePreference("posts", locale)).
+			Scopes(preloadComments).
 			Where("slug = ? AND status = ?", id, models.PostStatusPublished).
-			First(&post).Error
+			Take(&post).Error
+	}
+
+	if err == gorm.ErrRecordNotFound {
+		var history models.PostSlug
+		if lookupErr := h.db.Preload("Post").Scopes(h.locales.byLocalePreference("post_slugs", locale)).
+			Where("slug = ?", id).Take(&history).Error; lookupErr == nil &&
+			history.Post != nil && history.Post.Status == models.PostStatusPublished &&
+			history.Post.Visibility != models.PostVisibilityPrivate {
+			c.Header("Location", path.Join(path.Dir(c.Request.URL.Path), url.PathEscape(history.Post.Slug))+"?lang="+url.QueryEscape(history.Post.Locale))
+			c.JSON(http.StatusMovedPermanently, gin.H{
+				"moved_to": history.Post.Slug,
+				"locale":   history.Post.Locale,
+				"post_id":  history.Post.ID,
+			})
+			return
+		}
+	}
+
+	if err == nil && parseErr != nil && negotiated && post.Locale != locale && post.TranslationGroupID != nil {
+		var translation models.Post
+		if h.db.Scopes(preloadByline, preloadTaxonomy, publicTranslations(*post.TranslationGroupID)).
+			Scopes(preloadComments).
+			Where("posts.locale = ?", locale).
+			First(&translation).Error == nil {
+			post = translation
+		}
 	}
 
 	if err != nil {
@@ -211,10 +582,34 @@ func (h *PostHandler) GetPost(c *gin.Context) {
 		return
 	}
 
-	h.db.Model(&post).UpdateColumn("view_count", gorm.Expr("view_count + ?", 1))
+	if !h.applyVisibility(c, &post) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Post not found",
+		})
+		return
+	}
+	if !post.Locked {
+		h.ensureRendered(&post)
+	}
+
+	viewerID, member := middleware.GetUserID(c)
+	h.views.Record(post.ID, views.Viewer{
+		UserID:    viewerID,
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+		Referrer:  c.Request.Referer(),
+		Editor:    member && h.canEdit(viewerID, post.ID),
+	})
+	markViewerState(h.db, viewerID, []*models.Post{&post})
 
+	c.Header("ETag", versionETag(post.Version))
+	c.Header("Content-Language", post.Locale)
+	c.Header("Vary", "Accept-Language")
 	c.JSON(http.StatusOK, gin.H{
-		"post": post,
+		"post":       post,
+		"meta":       h.postMeta(&post),
+		"series":     seriesNavigation(h.db, post.ID),
+		"alternates": h.locales.alternates(h.db, &post),
 	})
 }
 
@@ -237,7 +632,7 @@ func (h *PostHandler) UpdatePost(c *gin.Context) {
 	}
 
 	var post models.Post
-	if err := h.db.Where("id = ? AND author_id = ?", postUUID, userID).First(&post).Error; err != nil {
+	if err := h.db.Scopes(canEditPost(userID)).Where("posts.id = ?", postUUID).First(&post).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusNotFound, gin.H{
 				"error": "Post not found or not authorized",
@@ -250,6 +645,11 @@ func (h *PostHandler) UpdatePost(c *gin.Context) {
 		return
 	}
 
+	if !checkIfMatch(c, post.Version) {
+		h.respondPostConflict(c, post.ID)
+		return
+	}
+
 	var req UpdatePostRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -262,22 +662,201 @@ func (h *PostHandler) UpdatePost(c *gin.Context) {
 
 	if req.Title != "" {
 		updates["title"] = req.Title
-		updates["slug"] = generateSlug(req.Title)
 	}
-	if req.Content != "" {
-		updates["content"] = req.Content
+
+	pinned := post.SlugPinned
+	if req.SlugPinned != nil {
+		pinned = *req.SlugPinned
+	}
+
+	locale := post.Locale
+	if req.Locale != "" && req.Locale != post.Locale {
+		if !h.locales.config.Supported(req.Locale) {
+			h.locales.respondInvalid(c)
+			return
+		}
+		if post.TranslationGroupID != nil {
+			var taken int64
+			h.db.Model(&models.Post{}).
+				Where("translation_group_id = ? AND locale = ? AND id <> ?", post.TranslationGroupID, req.Locale, post.ID).
+				Count(&taken)
+			if taken > 0 {
+				c.JSON(http.StatusConflict, gin.H{
+					"error": errTranslationConflict.Error(),
+				})
+				return
+			}
+		}
+		locale = req.Locale
+		updates["locale"] = locale
+	}
+
+	newSlug := post.Slug
+	switch {
+	case req.Slug != "":
+		newSlug, err = resolveSlug(h.db, locale, "", req.Slug, post.ID)
+		pinned = true
+	case !pinned && req.Title != "" && req.Title != post.Title:
+		newSlug, err = resolveSlug(h.db, locale, req.Title, "", post.ID)
+	case locale != post.Locale:
+		// The slug moves with the post, so it must be free in the new locale.
+		var taken bool
+		if taken, err = slugTaken(h.db, locale, post.Slug, post.ID); err == nil && taken {
+			err = errSlugTaken
+		}
+	}
+	if err != nil {
+		respondSlugError(c, err)
+		return
+	}
+	if newSlug != post.Slug {
+		updates["slug"] = newSlug
+	}
+	if pinned != post.SlugPinned {
+		updates["slug_pinned"] = pinned
+	}
+	if req.Content != "" && req.Content != post.Content {
+		rendered := post
+		rendered.Content = req.Content
+		if err := h.renderContent(&rendered); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Failed to render content",
+			})
+			return
+		}
+		updates["content"] = rendered.Content
+		updates["content_html"] = rendered.ContentHTML
+		updates["content_hash"] = rendered.ContentHash
+		updates["toc"] = rendered.TOC
+		updates["word_count"] = rendered.WordCount
+		updates["reading_time"] = rendered.ReadingTime
+		if rendered.ExcerptGenerated {
+			updates["excerpt"] = rendered.Excerpt
+			updates["excerpt_generated"] = true
+		}
 	}
 	if req.Excerpt != "" {
 		updates["excerpt"] = req.Excerpt
+		updates["excerpt_generated"] = false
 	}
 	if req.FeaturedImage != "" {
 		updates["featured_image"] = req.FeaturedImage
 	}
+	access, err := visibilityUpdate(post.Visibility, post.PasswordHash != "", req.Visibility, req.Password)
+	if err != nil {
+		respondVisibilityError(c, err)
+		return
+	}
+	for column, value := range access {
+		updates[column] = value
+	}
+	if req.SEO != nil {
+		seo, err := seoUpdate(post.SEO, req.SEO)
+		if err != nil {
+			respondSEOError(c, err)
+			return
+		}
+		for column, value := range seoColumns(seo) {
+			updates[column] = value
+		}
+	}
+
+	status := models.PostStatus(req.Status)
+	publishing := req.Status != "" && status != post.Status &&
+		(status == models.PostStatusPublished || status == models.PostStatusScheduled)
 	if req.Status != "" {
-		updates["status"] = req.Status
+		role, ok := middleware.GetUserRole(c, h.db)
+		if !ok {
+			respondRoleError(c)
+			return
+		}
+		if err := models.CheckStatusChange(h.db, role, &post, status); err != nil {
+			respondStatusError(c, err)
+			return
+		}
+		// An approval covers the version that was reviewed, so a contributor
+		// cannot publish and change the post in the same request.
+		if publishing && role == models.UserRoleContributor &&
+			(len(updates) > 0 || len(req.Tags) > 0 || req.CategoryID != nil || req.SecondaryCategoryIDs != nil) {
+			respondStatusError(c, errPublishWithChanges)
+			return
+		}
+		updates["status"] = status
+		// BeforeUpdate still runs for a map update and bumps the version,
+		// but its change to PublishedAt is not saved, so the publish time is
+		// set here for the published sort.
+		if status == models.PostStatusPublished && post.PublishedAt == nil {
+			updates["published_at"] = time.Now()
+		}
 	}
 
-	if err := h.db.Model(&post).Updates(updates).Error; err != nil {
+	target := post.Status
+	if req.Status != "" {
+		target = status
+	}
+	scheduledAt, err := scheduleFor(post.Status, target, req.ScheduledAt, post.ScheduledAt)
+	if err != nil {
+		respondStatusError(c, err)
+		return
+	}
+	if !sameTime(scheduledAt, post.ScheduledAt) {
+		updates["scheduled_at"] = scheduledAt
+	}
+
+	if err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := changeSlug(tx, &post, newSlug); err != nil {
+			return err
+		}
+		if publishing && post.Status == models.PostStatusInReview {
+			if err := models.ClosePendingReview(tx, &post, userID, middleware.IsEditor(c, h.db)); err != nil {
+				return err
+			}
+		}
+		// Compare and swap on the version read above, so a write that lands
+		// between the If-Match check and this update is not overwritten.
+		result := tx.Model(&post).Where("version = ?", post.Version).Updates(updates)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errVersionConflict
+		}
+		linked := post
+		linked.Slug, linked.Locale = newSlug, locale
+		if content, changed := updates["content"].(string); changed {
+			if err := annotations.Reanchor(tx, post.ID, content, post.Version+1); err != nil {
+				return err
+			}
+			if _, err := h.duplicates.Check(tx, duplicates.KindPost, post.ID, content); err != nil {
+				return err
+			}
+			linked.Content = content
+		}
+		if linked.Content != post.Content || linked.Locale != post.Locale {
+			if err := h.links.Update(tx, &linked); err != nil {
+				return err
+			}
+		}
+		if linked.Slug != post.Slug || linked.Locale != post.Locale {
+			if err := linkgraph.Attach(tx, &linked); err != nil {
+				return err
+			}
+		}
+		if err := discardAutosave(tx, post.ID, userID); err != nil {
+			return err
+		}
+		return setPostCategories(tx, &post, req.CategoryID, req.SecondaryCategoryIDs)
+	}); err != nil {
+		if err == errVersionConflict {
+			h.respondPostConflict(c, post.ID)
+			return
+		}
+		if err == errCategoryNotFound {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Category not found",
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to update post",
 		})
@@ -293,13 +872,19 @@ func (h *PostHandler) UpdatePost(c *gin.Context) {
 		}
 	}
 
-	if err := h.db.Preload("Author").Preload("Tags").First(&post, post.ID).Error; err != nil {
+	if err := h.db.Scopes(preloadByline, preloadTaxonomy).First(&post, post.ID).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to load updated post",
 		})
 		return
 	}
 
+	_, excerptChanged := updates["excerpt"]
+	if len(req.Tags) > 0 || req.Status != "" || req.Title != "" || excerptChanged || req.Visibility != "" {
+		h.related.Enqueue(post.ID)
+	}
+
+	c.Header("ETag", versionETag(post.Version))
 	c.JSON(http.StatusOK, gin.H{
 		"post": post,
 	})
@@ -323,7 +908,21 @@ func (h *PostHandler) DeletePost(c *gin.Context) {
 		return
 	}
 
-	result := h.db.Where("id = ? AND author_id = ?", postUUID, userID).Delete(&models.Post{})
+	db := h.db.Scopes(ownsPost(userID)).Where("posts.id = ?", postUUID)
+	guarded := false
+	if versions := ifMatchVersions(c); versions != nil {
+		var post models.Post
+		if err := h.db.Scopes(ownsPost(userID)).Select("id", "version").Where("posts.id = ?", postUUID).First(&post).Error; err == nil {
+			if !versions[post.Version] {
+				h.respondPostConflict(c, post.ID)
+				return
+			}
+			db = db.Where("posts.version = ?", post.Version)
+			guarded = true
+		}
+	}
+
+	result := db.Delete(&models.Post{})
 	if result.Error != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to delete post",
@@ -332,14 +931,20 @@ func (h *PostHandler) DeletePost(c *gin.Context) {
 	}
 
 	if result.RowsAffected == 0 {
+		if guarded {
+			h.respondPostConflict(c, postUUID)
+			return
+		}
 		c.JSON(http.StatusNotFound, gin.H{
 			"error": "Post not found or not authorized",
 		})
 		return
 	}
 
+	h.related.Enqueue(postUUID)
+
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Post deleted successfully",
+		"message": "Post moved to trash",
 	})
 }
 
@@ -372,19 +977,88 @@ func (h *PostHandler) associateTags(post *models.Post, tagNames []string) error
 	return nil
 }
 
-func generateSlug(title string) string {
-	slug := strings.ToLower(title)
-	slug = strings.ReplaceAll(slug, " ", "-")
-	slug = strings.ReplaceAll(slug, "_", "-")
+// respondPostConflict answers a failed If-Match with the post as it is now.
+func (h *PostHandler) respondPostConflict(c *gin.Context, postID uuid.UUID) {
+	var current models.Post
+	if err := h.db.Scopes(preloadByline, preloadTaxonomy).First(&current, "id = ?", postID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Post not found",
+		})
+		return
+	}
+	respondVersionConflict(c, "post", current, current.Version)
+}
 
-	allowedChars := "abcdefghijklmnopqrstuvwxyz0123456789-"
-	var result strings.Builder
+// renderContent renders post.Content and stores the HTML and derived
+// metadata on the post. The content hash marks which source was rendered. An
+// excerpt is generated unless one was written.
+func (h *PostHandler) renderContent(post *models.Post) error {
+	result, err := h.renderer.Render(post.Content)
+	if err != nil {
+		return err
+	}
 
-	for _, char := range slug {
-		if strings.ContainsRune(allowedChars, char) {
-			result.WriteRune(char)
-		}
+	toc := make(models.TableOfContents, 0, len(result.TOC))
+	for _, heading := range result.TOC {
+		toc = append(toc, models.TOCEntry{
+			Level: heading.Level,
+			ID:    heading.ID,
+			Text:  heading.Text,
+		})
+	}
+
+	post.ContentHTML = result.HTML
+	post.ContentHash = markdown.Hash(post.Content)
+	post.TOC = toc
+	post.WordCount = result.WordCount
+	post.ReadingTime = result.ReadingTime
+	if post.Excerpt == "" || post.ExcerptGenerated {
+		post.Excerpt = result.Excerpt
+		post.ExcerptGenerated = true
 	}
+	return nil
+}
 
-	return strings.Trim(result.String(), "-")
+// ensureRendered re-renders posts whose cached output is missing or was
+// produced from different content, or that lack an excerpt, and persists
+// the refreshed cache.
+func (h *PostHandler) ensureRendered(post *models.Post) {
+	if post.ContentHash == markdown.Hash(post.Content) && (post.Excerpt != "" || post.ExcerptGenerated) {
+		return
+	}
+
+	if err := h.renderContent(post); err != nil {
+		return
+	}
+
+	h.db.Model(&models.Post{}).Where("id = ?", post.ID).UpdateColumns(map[string]interface{}{
+		"content_html":      post.ContentHTML,
+		"content_hash":      post.ContentHash,
+		"toc":               post.TOC,
+		"word_count":        post.WordCount,
+		"reading_time":      post.ReadingTime,
+		"excerpt":           post.Excerpt,
+		"excerpt_generated": post.ExcerptGenerated,
+	})
+}
+
+func respondSlugError(c *gin.Context, err error) {
+	switch err {
+	case errInvalidSlug:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid slug",
+		})
+	case errSlugTaken:
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Slug already in use",
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to resolve slug",
+		})
+	}
+}
+
+func generateSlug(title string) string {
+	return slugify.Make(title)
 }
@@ -0,0 +1,248 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/yairfalse/modern-cloud-app/backend/internal/api/middleware"
+	"github.com/yairfalse/modern-cloud-app/backend/internal/database/models"
+)
+
+type CreatePreviewLinkRequest struct {
+	ExpiresIn string `json:"expires_in"`
+	Note      string `json:"note" binding:"max=255"`
+}
+
+func (h *PostHandler) CreatePreviewLink(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	postUUID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid post ID",
+		})
+		return
+	}
+
+	var req CreatePreviewLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ttl := h.jwtConfig.PreviewTTL
+	if req.ExpiresIn != "" {
+		ttl, err = time.ParseDuration(req.ExpiresIn)
+		if err != nil || ttl <= 0 || ttl > h.jwtConfig.PreviewMaxTTL {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid expires_in, must be a positive duration up to " + h.jwtConfig.PreviewMaxTTL.String(),
+			})
+			return
+		}
+	}
+
+	var post models.Post
+	if err := h.db.Scopes(canEditPost(userID)).Where("posts.id = ?", postUUID).First(&post).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Post not found or not authorized",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch post",
+			})
+		}
+		return
+	}
+
+	link := models.PreviewLink{
+		ID:        uuid.New(),
+		PostID:    post.ID,
+		CreatedBy: userID,
+		Note:      req.Note,
+		ExpiresAt: time.Now().UTC().Add(ttl),
+	}
+
+	token, err := h.jwtManager.GeneratePreviewToken(link.ID, post.ID, link.ExpiresAt)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to generate preview token",
+		})
+		return
+	}
+
+	if err := h.db.Create(&link).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to create preview link",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"preview_link": link,
+		"token":        token,
+		"url":          "/api/v1/preview/" + token,
+	})
+}
+
+func (h *PostHandler) GetPreviewLinks(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	postUUID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid post ID",
+		})
+		return
+	}
+
+	var post models.Post
+	if err := h.db.Scopes(canEditPost(userID)).Where("posts.id = ?", postUUID).First(&post).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Post not found or not authorized",
+		})
+		return
+	}
+
+	var links []models.PreviewLink
+	if err := h.db.Where("post_id = ?", post.ID).
+		Order("created_at DESC").
+		Find(&links).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch preview links",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"preview_links": links,
+	})
+}
+
+func (h *PostHandler) RevokePreviewLink(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	postUUID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid post ID",
+		})
+		return
+	}
+
+	linkUUID, err := uuid.Parse(c.Param("linkId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid preview link ID",
+		})
+		return
+	}
+
+	var post models.Post
+	if err := h.db.Scopes(canEditPost(userID)).Where("posts.id = ?", postUUID).First(&post).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Post not found or not authorized",
+		})
+		return
+	}
+
+	result := h.db.Model(&models.PreviewLink{}).
+		Where("id = ? AND post_id = ? AND revoked_at IS NULL", linkUUID, post.ID).
+		Update("revoked_at", time.Now().UTC())
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to revoke preview link",
+		})
+		return
+	}
+
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Preview link not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Preview link revoked successfully",
+	})
+}
+
+// GetPreview serves a post read-only through a preview token. It works for
+// any status and deliberately does not count a view.
+func (h *PostHandler) GetPreview(c *gin.Context) {
+	claims, err := h.jwtManager.ValidatePreviewToken(c.Param("token"))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Invalid or expired preview link",
+		})
+		return
+	}
+
+	linkUUID, err := uuid.Parse(claims.ID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Invalid or expired preview link",
+		})
+		return
+	}
+
+	var link models.PreviewLink
+	if err := h.db.Where("id = ? AND post_id = ?", linkUUID, claims.PostID).First(&link).Error; err != nil || !link.IsActive() {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Invalid or expired preview link",
+		})
+		return
+	}
+
+	var post models.Post
+	if err := h.db.Scopes(preloadByline, preloadTaxonomy).First(&post, "id = ?", link.PostID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Post not found",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch post",
+			})
+		}
+		return
+	}
+
+	h.ensureRendered(&post)
+
+	c.Header("X-Robots-Tag", "noindex, nofollow")
+	c.Header("Cache-Control", "private, no-store")
+	c.JSON(http.StatusOK, gin.H{
+		"post":       post,
+		"preview":    true,
+		"expires_at": link.ExpiresAt,
+	})
+}
@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/yairfalse/modern-cloud-app/backend/internal/database/models"
+)
+
+type RedirectHandler struct {
+	db *gorm.DB
+}
+
+func NewRedirectHandler(db *gorm.DB) *RedirectHandler {
+	return &RedirectHandler{db: db}
+}
+
+// ResolveLegacyURL looks up a path from a previous site, such as an old
+// WordPress permalink, and redirects to the post that replaced it.
+func (h *RedirectHandler) ResolveLegacyURL(c *gin.Context) {
+	path := models.LegacyPath(c.Query("path"))
+	if path == "" || path == "/" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Query parameter path is required",
+		})
+		return
+	}
+
+	var legacy models.LegacyURL
+	if err := h.db.Preload("Post").Where("path = ?", path).First(&legacy).Error; err != nil ||
+		legacy.Post == nil || legacy.Post.Status != models.PostStatusPublished ||
+		legacy.Post.Visibility == models.PostVisibilityPrivate {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "No redirect for this path",
+		})
+		return
+	}
+
+	// Slugs are only unique within a locale, so the locale picks the post.
+	c.Header("Location", "/api/v1/posts/"+url.PathEscape(legacy.Post.Slug)+"?lang="+url.QueryEscape(legacy.Post.Locale))
+	c.JSON(http.StatusMovedPermanently, gin.H{
+		"moved_to": legacy.Post.Slug,
+		"locale":   legacy.Post.Locale,
+		"post_id":  legacy.Post.ID,
+	})
+}
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/yairfalse/modern-cloud-app/backend/internal/database/models"
+)
+
+// GetRelatedPosts serves the precomputed recommendations for a published
+// post. When none have been computed yet it schedules a computation and
+// returns an empty list.
+func (h *PostHandler) GetRelatedPosts(c *gin.Context) {
+	postUUID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid post ID",
+		})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "5"))
+	if err != nil || limit < 1 {
+		limit = 5
+	}
+	if limit > 20 {
+		limit = 20
+	}
+
+	var post models.Post
+	if err := h.db.Select("id", "locale").
+		Where("id = ? AND status = ? AND visibility <> ?", postUUID, models.PostStatusPublished, models.PostVisibilityPrivate).
+		First(&post).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Post not found",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch post",
+			})
+		}
+		return
+	}
+
+	var related []models.RelatedPost
+	if err := h.db.Joins("RelatedPost").
+		Where("related_posts.post_id = ? AND \"RelatedPost\".status = ? AND \"RelatedPost\".visibility IN ? AND \"RelatedPost\".locale = ?", post.ID, models.PostStatusPublished, listedVisibilities, post.Locale).
+		Order("related_posts.score DESC").
+		Limit(limit).
+		Find(&related).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch related posts",
+		})
+		return
+	}
+
+	if len(related) == 0 {
+		var computed int64
+		h.db.Model(&models.RelatedPost{}).Where("post_id = ?", post.ID).Count(&computed)
+		if computed == 0 {
+			h.related.Enqueue(post.ID)
+		}
+	}
+
+	listed := make([]*models.Post, 0, len(related))
+	for _, r := range related {
+		if r.RelatedPost != nil {
+			listed = append(listed, r.RelatedPost)
+		}
+	}
+	redactListed(c, listed)
+
+	posts := make([]gin.H, 0, len(related))
+	for _, r := range related {
+		if r.RelatedPost == nil {
+			continue
+		}
+		posts = append(posts, gin.H{
+			"id":             r.RelatedPost.ID,
+			"title":          r.RelatedPost.Title,
+			"slug":           r.RelatedPost.Slug,
+			"excerpt":        r.RelatedPost.Excerpt,
+			"featured_image": r.RelatedPost.FeaturedImage,
+			"published_at":   r.RelatedPost.PublishedAt,
+			"reading_time":   r.RelatedPost.ReadingTime,
+			"score":          r.Score,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"related": posts,
+	})
+}
@@ -0,0 +1,432 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/yairfalse/modern-cloud-app/backend/internal/api/middleware"
+	"github.com/yairfalse/modern-cloud-app/backend/internal/database/models"
+)
+
+var errPublishWithChanges = errors.New("publish the approved version without further changes")
+
+type ReviewHandler struct {
+	db *gorm.DB
+}
+
+type SubmitReviewRequest struct {
+	ReviewerID *uuid.UUID `json:"reviewer_id"`
+	Note       string     `json:"note"`
+}
+
+type AssignReviewerRequest struct {
+	ReviewerID uuid.UUID `json:"reviewer_id" binding:"required"`
+}
+
+type ReviewDecisionRequest struct {
+	Comment string `json:"comment"`
+}
+
+func NewReviewHandler(db *gorm.DB) *ReviewHandler {
+	return &ReviewHandler{db: db}
+}
+
+// respondRoleError answers a request whose status change depends on a role
+// that could not be read, rather than treating the caller as unrestricted.
+func respondRoleError(c *gin.Context) {
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"error": "Failed to fetch user role",
+	})
+}
+
+func respondStatusError(c *gin.Context, err error) {
+	switch err {
+	case models.ErrInvalidStatus:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid status, must be one of draft, in_review, scheduled, published, archived",
+		})
+	case errScheduleRequired, errScheduleNotNeeded:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+	case models.ErrReviewRequired:
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": err.Error(),
+		})
+	default:
+		c.JSON(http.StatusConflict, gin.H{
+			"error": err.Error(),
+		})
+	}
+}
+
+// SubmitForReview moves a draft to in_review and opens a review round,
+// optionally asking a specific editor to review it.
+func (h *ReviewHandler) SubmitForReview(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	var req SubmitReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	post, ok := h.loadPost(c, canEditPost(userID))
+	if !ok {
+		return
+	}
+
+	if !post.Status.CanTransitionTo(models.PostStatusInReview) || post.Status == models.PostStatusInReview {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Only drafts can be submitted for review",
+		})
+		return
+	}
+
+	if req.ReviewerID != nil && !h.isReviewer(*req.ReviewerID) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Reviewer must be an active editor or admin",
+		})
+		return
+	}
+
+	review := models.PostReview{
+		PostID:      post.ID,
+		SubmittedBy: userID,
+		ReviewerID:  req.ReviewerID,
+		Status:      models.ReviewStatusPending,
+		Note:        req.Note,
+	}
+
+	if err := h.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(post).Where("version = ?", post.Version).Update("status", models.PostStatusInReview)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errVersionConflict
+		}
+		return tx.Create(&review).Error
+	}); err != nil {
+		if err == errVersionConflict {
+			c.JSON(http.StatusConflict, gin.H{
+				"error": "Post was modified concurrently, try again",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to submit post for review",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"review": review,
+	})
+}
+
+// GetReviews lists every review round of a post, newest first.
+func (h *ReviewHandler) GetReviews(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	scope := canEditPost(userID)
+	if middleware.IsEditor(c, h.db) {
+		scope = func(db *gorm.DB) *gorm.DB { return db }
+	}
+	post, ok := h.loadPost(c, scope)
+	if !ok {
+		return
+	}
+
+	var reviews []models.PostReview
+	if err := h.db.Preload("Submitter").Preload("Reviewer").Preload("Decider").
+		Where("post_id = ?", post.ID).
+		Order("created_at DESC").
+		Find(&reviews).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch reviews",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"reviews": reviews,
+	})
+}
+
+// AssignReviewer sets or changes who reviews the post's open review.
+func (h *ReviewHandler) AssignReviewer(c *gin.Context) {
+	var req AssignReviewerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if !h.isReviewer(req.ReviewerID) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Reviewer must be an active editor or admin",
+		})
+		return
+	}
+
+	review, ok := h.loadOpenReview(c)
+	if !ok {
+		return
+	}
+
+	if err := h.db.Model(review).Update("reviewer_id", req.ReviewerID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to assign reviewer",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"review": review,
+	})
+}
+
+// ApproveReview approves the post as it is now. The author can publish it
+// until it is edited again.
+func (h *ReviewHandler) ApproveReview(c *gin.Context) {
+	h.decide(c, models.ReviewStatusApproved)
+}
+
+// RequestChanges sends the post back to draft with the reviewer's comments.
+func (h *ReviewHandler) RequestChanges(c *gin.Context) {
+	h.decide(c, models.ReviewStatusChangesRequested)
+}
+
+func (h *ReviewHandler) decide(c *gin.Context, decision models.ReviewStatus) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	var req ReviewDecisionRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+	if decision == models.ReviewStatusChangesRequested && req.Comment == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "A comment is required when requesting changes",
+		})
+		return
+	}
+
+	review, ok := h.loadOpenReview(c)
+	if !ok {
+		return
+	}
+	if review.Status != models.ReviewStatusPending {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Review has already been decided",
+		})
+		return
+	}
+
+	// Once a reviewer is assigned only they, or an admin, may decide.
+	if review.ReviewerID != nil && *review.ReviewerID != userID {
+		if role, _ := middleware.GetUserRole(c, h.db); role != models.UserRoleAdmin {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "This review is assigned to another reviewer",
+			})
+			return
+		}
+	}
+
+	if err := h.db.Transaction(func(tx *gorm.DB) error {
+		var post models.Post
+		if err := tx.Select("id", "version").First(&post, "id = ?", review.PostID).Error; err != nil {
+			return err
+		}
+
+		now := time.Now().UTC()
+		if err := tx.Model(review).Updates(map[string]interface{}{
+			"status":       decision,
+			"decided_by":   userID,
+			"decided_at":   now,
+			"comment":      req.Comment,
+			"post_version": post.Version,
+		}).Error; err != nil {
+			return err
+		}
+
+		if decision == models.ReviewStatusChangesRequested {
+			return tx.Model(&post).Update("status", models.PostStatusDraft).Error
+		}
+		return nil
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to record review decision",
+		})
+		return
+	}
+
+	h.db.Preload("Decider").First(review, "id = ?", review.ID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"review": review,
+	})
+}
+
+// WithdrawReview takes a post out of review and back to draft.
+func (h *ReviewHandler) WithdrawReview(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	post, ok := h.loadPost(c, canEditPost(userID))
+	if !ok {
+		return
+	}
+	if post.Status != models.PostStatusInReview {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Post is not in review",
+		})
+		return
+	}
+
+	if err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.PostReview{}).
+			Where("post_id = ? AND status IN ?", post.ID,
+				[]models.ReviewStatus{models.ReviewStatusPending, models.ReviewStatusApproved}).
+			Update("status", models.ReviewStatusWithdrawn).Error; err != nil {
+			return err
+		}
+		return tx.Model(post).Update("status", models.PostStatusDraft).Error
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to withdraw review",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Review withdrawn, post moved back to draft",
+	})
+}
+
+// GetReviewQueue lists pending reviews assigned to the caller or to nobody.
+func (h *ReviewHandler) GetReviewQueue(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	var reviews []models.PostReview
+	if err := h.db.Preload("Post").Preload("Submitter").
+		Where("status = ? AND (reviewer_id = ? OR reviewer_id IS NULL)", models.ReviewStatusPending, userID).
+		Order("created_at ASC").
+		Find(&reviews).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch reviews",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"reviews": reviews,
+	})
+}
+
+func (h *ReviewHandler) isReviewer(userID uuid.UUID) bool {
+	var count int64
+	h.db.Model(&models.User{}).
+		Where("id = ? AND is_active = ? AND role IN ?", userID, true,
+			[]models.UserRole{models.UserRoleEditor, models.UserRoleAdmin}).
+		Count(&count)
+	return count > 0
+}
+
+func (h *ReviewHandler) loadPost(c *gin.Context, scope func(*gorm.DB) *gorm.DB) (*models.Post, bool) {
+	postUUID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid post ID",
+		})
+		return nil, false
+	}
+
+	var post models.Post
+	if err := h.db.Scopes(scope).Where("posts.id = ?", postUUID).First(&post).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Post not found or not authorized",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch post",
+			})
+		}
+		return nil, false
+	}
+	return &post, true
+}
+
+// loadOpenReview returns the latest review of a post that is in review.
+func (h *ReviewHandler) loadOpenReview(c *gin.Context) (*models.PostReview, bool) {
+	postUUID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid post ID",
+		})
+		return nil, false
+	}
+
+	var review models.PostReview
+	if err := h.db.Joins("JOIN posts ON posts.id = post_reviews.post_id AND posts.deleted_at IS NULL").
+		Where("post_reviews.post_id = ? AND posts.status = ?", postUUID, models.PostStatusInReview).
+		Order("post_reviews.created_at DESC").
+		First(&review).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Post is not in review",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch review",
+			})
+		}
+		return nil, false
+	}
+	return &review, true
+}
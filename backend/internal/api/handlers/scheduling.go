@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"errors"
+	"time"
+
+	"github.com/yairfalse/modern-cloud-app/backend/internal/database/models"
+)
+
+var (
+	errScheduleRequired  = errors.New("scheduled posts need a scheduled_at in the future")
+	errScheduleNotNeeded = errors.New("scheduled_at can only be set on scheduled posts")
+)
+
+// scheduleFor returns the scheduled_at to store for a post moving from
+// previous to status, given the requested time and the one it has. The
+// scheduling worker publishes the post once that time has passed. Posts
+// scheduled before scheduled_at existed have none, and keep it that way
+// until a time is requested.
+func scheduleFor(previous, status models.PostStatus, requested, current *time.Time) (*time.Time, error) {
+	if status != models.PostStatusScheduled {
+		if requested != nil {
+			return nil, errScheduleNotNeeded
+		}
+		return nil, nil
+	}
+	if requested == nil || (current != nil && requested.Equal(*current)) {
+		if current == nil && previous != models.PostStatusScheduled {
+			return nil, errScheduleRequired
+		}
+		return current, nil
+	}
+	if !requested.After(time.Now()) {
+		return nil, errScheduleRequired
+	}
+	at := requested.UTC()
+	return &at, nil
+}
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/yairfalse/modern-cloud-app/backend/internal/database/models"
+)
+
+func TestScheduleFor(t *testing.T) {
+	future := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	later := future.Add(time.Hour)
+	past := time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		name      string
+		previous  models.PostStatus
+		status    models.PostStatus
+		requested *time.Time
+		current   *time.Time
+		want      *time.Time
+		wantErr   error
+	}{
+		{
+			name:   "draft stays unscheduled",
+			status: models.PostStatusDraft,
+		},
+		{
+			name:      "time on a draft",
+			status:    models.PostStatusDraft,
+			requested: &future,
+			wantErr:   errScheduleNotNeeded,
+		},
+		{
+			name:     "leaving scheduled clears the time",
+			previous: models.PostStatusScheduled,
+			status:   models.PostStatusPublished,
+			current:  &future,
+		},
+		{
+			name:      "scheduling in the future",
+			previous:  models.PostStatusDraft,
+			status:    models.PostStatusScheduled,
+			requested: &future,
+			want:      &future,
+		},
+		{
+			name:     "scheduling without a time",
+			previous: models.PostStatusDraft,
+			status:   models.PostStatusScheduled,
+			wantErr:  errScheduleRequired,
+		},
+		{
+			name:      "scheduling in the past",
+			previous:  models.PostStatusDraft,
+			status:    models.PostStatusScheduled,
+			requested: &past,
+			wantErr:   errScheduleRequired,
+		},
+		{
+			name:     "scheduled post keeps its time",
+			previous: models.PostStatusScheduled,
+			status:   models.PostStatusScheduled,
+			current:  &future,
+			want:     &future,
+		},
+		{
+			name:      "scheduled post is moved",
+			previous:  models.PostStatusScheduled,
+			status:    models.PostStatusScheduled,
+			requested: &later,
+			current:   &future,
+			want:      &later,
+		},
+		{
+			name:     "scheduled post from before scheduled_at can be edited",
+			previous: models.PostStatusScheduled,
+			status:   models.PostStatusScheduled,
+		},
+		{
+			name:      "scheduled post from before scheduled_at can be given a time",
+			previous:  models.PostStatusScheduled,
+			status:    models.PostStatusScheduled,
+			requested: &future,
+			want:      &future,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := scheduleFor(tt.previous, tt.status, tt.requested, tt.current)
+			if err != tt.wantErr {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if !sameTime(got, tt.want) {
+				t.Errorf("scheduled_at = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yairfalse/modern-cloud-app/backend/internal/database/models"
+	"github.com/yairfalse/modern-cloud-app/backend/pkg/markdown"
+)
+
+// metaDescriptionLength is how much of the excerpt is used as the description
+// when none was written; search engines cut longer ones off.
+const metaDescriptionLength = 160
+
+var (
+	errInvalidCanonicalURL = errors.New("invalid canonical URL")
+	errInvalidOGImage      = errors.New("invalid Open Graph image")
+	errInvalidTwitterCard  = errors.New("invalid Twitter card")
+)
+
+// SEORequest sets a post's SEO overrides. Omitted fields are left unchanged
+// and empty strings clear them.
+type SEORequest struct {
+	MetaTitle       *string `json:"meta_title" binding:"omitempty,max=255"`
+	MetaDescription *string `json:"meta_description" binding:"omitempty,max=500"`
+	CanonicalURL    *string `json:"canonical_url" binding:"omitempty,max=2048"`
+	OGTitle         *string `json:"og_title" binding:"omitempty,max=255"`
+	OGDescription   *string `json:"og_description" binding:"omitempty,max=500"`
+	OGImage         *string `json:"og_image" binding:"omitempty,max=2048"`
+	TwitterCard     *string `json:"twitter_card"`
+	NoIndex         *bool   `json:"noindex"`
+}
+
+func respondSEOError(c *gin.Context, err error) {
+	switch err {
+	case errInvalidCanonicalURL:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid canonical URL, must be an absolute http or https URL",
+		})
+	case errInvalidOGImage:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid Open Graph image, must be an http or https URL or a path starting with /",
+		})
+	case errInvalidTwitterCard:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid Twitter card, must be summary or summary_large_image",
+		})
+	}
+}
+
+// seoUpdate applies req to current and validates the result.
+func seoUpdate(current models.PostSEO, req *SEORequest) (models.PostSEO, error) {
+	seo := current
+	if req == nil {
+		return seo, nil
+	}
+
+	set := func(field *string, value *string) {
+		if value != nil {
+			*field = strings.TrimSpace(*value)
+		}
+	}
+	set(&seo.MetaTitle, req.MetaTitle)
+	set(&seo.MetaDescription, req.MetaDescription)
+	set(&seo.CanonicalURL, req.CanonicalURL)
+	set(&seo.OGTitle, req.OGTitle)
+	set(&seo.OGDescription, req.OGDescription)
+	set(&seo.OGImage, req.OGImage)
+	if req.TwitterCard != nil {
+		seo.TwitterCard = models.TwitterCard(strings.TrimSpace(*req.TwitterCard))
+	}
+	if req.NoIndex != nil {
+		seo.NoIndex = *req.NoIndex
+	}
+
+	if seo.CanonicalURL != "" && !absoluteHTTPURL(seo.CanonicalURL) {
+		return seo, errInvalidCanonicalURL
+	}
+	if seo.OGImage != "" && !absoluteHTTPURL(seo.OGImage) &&
+		(!strings.HasPrefix(seo.OGImage, "/") || strings.HasPrefix(seo.OGImage, "//")) {
+		return seo, errInvalidOGImage
+	}
+	if seo.TwitterCard != "" && !seo.TwitterCard.Valid() {
+		return seo, errInvalidTwitterCard
+	}
+	return seo, nil
+}
+
+// seoColumns returns the columns of an embedded PostSEO for an update.
+func seoColumns(seo models.PostSEO) map[string]interface{} {
+	return map[string]interface{}{
+		"seo_meta_title":       seo.MetaTitle,
+		"seo_meta_description": seo.MetaDescription,
+		"seo_canonical_url":    seo.CanonicalURL,
+		"seo_og_title":         seo.OGTitle,
+		"seo_og_description":   seo.OGDescription,
+		"seo_og_image":         seo.OGImage,
+		"seo_twitter_card":     seo.TwitterCard,
+		"seo_no_index":         seo.NoIndex,
+	}
+}
+
+func absoluteHTTPURL(raw string) bool {
+	u, err := url.Parse(raw)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// postMeta builds the metadata for a post's page: the document title and
+// description, canonical URL, robots directive, and Open Graph and Twitter
+// card tags. Overrides from post.SEO win over values derived from the post.
+func (h *PostHandler) postMeta(post *models.Post) gin.H {
+	seo := post.SEO
+
+	title := firstNonEmpty(seo.MetaTitle, post.Title)
+	description := firstNonEmpty(seo.MetaDescription, markdown.Truncate(post.Excerpt, metaDescriptionLength), title)
+
+	canonical := seo.CanonicalURL
+	if canonical == "" {
+		canonical = h.siteURL("/posts/" + url.PathEscape(post.Slug))
+		if post.Locale != h.locales.config.DefaultLocale {
+			canonical += "?lang=" + url.QueryEscape(post.Locale)
+		}
+	}
+
+	robots := "index, follow"
+	if seo.NoIndex || post.Visibility != models.PostVisibilityPublic {
+		robots = "noindex, nofollow"
+	}
+
+	image := firstNonEmpty(seo.OGImage, post.FeaturedImage)
+	if strings.HasPrefix(image, "/") && !strings.HasPrefix(image, "//") {
+		image = h.siteURL(image)
+	}
+
+	card := seo.TwitterCard
+	if card == "" {
+		card = models.TwitterCardSummary
+		if image != "" {
+			card = models.TwitterCardSummaryLargeImage
+		}
+	}
+
+	openGraph := gin.H{
+		"type":           "article",
+		"title":          firstNonEmpty(seo.OGTitle, title),
+		"description":    firstNonEmpty(seo.OGDescription, description),
+		"url":            canonical,
+		"image":          image,
+		"site_name":      h.seo.SiteName,
+		"locale":         post.Locale,
+		"published_time": post.PublishedAt,
+		"modified_time":  post.UpdatedAt.UTC().Format(time.RFC3339),
+	}
+	if post.Author != nil {
+		openGraph["author"] = firstNonEmpty(strings.TrimSpace(post.Author.FirstName+" "+post.Author.LastName), post.Author.Username)
+	}
+	if post.Category != nil {
+		openGraph["section"] = post.Category.Name
+	}
+	if len(post.Tags) > 0 {
+		tags := make([]string, 0, len(post.Tags))
+		for _, tag := range post.Tags {
+			tags = append(tags, tag.Name)
+		}
+		openGraph["tags"] = tags
+	}
+
+	return gin.H{
+		"title":         title,
+		"description":   description,
+		"canonical_url": canonical,
+		"robots":        robots,
+		"locale":        post.Locale,
+		"open_graph":    openGraph,
+		"twitter": gin.H{
+			"card":        card,
+			"title":       firstNonEmpty(seo.OGTitle, title),
+			"description": firstNonEmpty(seo.OGDescription, description),
+			"image":       image,
+			"site":        h.seo.TwitterSite,
+		},
+	}
+}
+
+// siteURL makes a site path absolute when the site URL is configured.
+func (h *PostHandler) siteURL(path string) string {
+	return h.seo.SiteURL + path
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
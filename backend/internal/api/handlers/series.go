@@ -0,0 +1,430 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/yairfalse/modern-cloud-app/backend/internal/api/middleware"
+	"github.com/yairfalse/modern-cloud-app/backend/internal/database/models"
+)
+
+type SeriesHandler struct {
+	db *gorm.DB
+}
+
+type CreateSeriesRequest struct {
+	Title       string `json:"title" binding:"required,min=1,max=255"`
+	Description string `json:"description"`
+}
+
+type UpdateSeriesRequest struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+}
+
+type SetSeriesPostsRequest struct {
+	PostIDs []uuid.UUID `json:"post_ids"`
+}
+
+// SeriesLink is a lightweight reference to a neighbouring post in a series.
+type SeriesLink struct {
+	ID    uuid.UUID `json:"id"`
+	Title string    `json:"title"`
+	Slug  string    `json:"slug"`
+}
+
+// SeriesNavigation describes where a post sits within its series, counting
+// only published posts.
+type SeriesNavigation struct {
+	ID       uuid.UUID   `json:"id"`
+	Title    string      `json:"title"`
+	Slug     string      `json:"slug"`
+	Position int         `json:"position"`
+	Total    int         `json:"total"`
+	Previous *SeriesLink `json:"previous"`
+	Next     *SeriesLink `json:"next"`
+}
+
+func NewSeriesHandler(db *gorm.DB) *SeriesHandler {
+	return &SeriesHandler{db: db}
+}
+
+func (h *SeriesHandler) GetSeriesList(c *gin.Context) {
+	type seriesSummary struct {
+		models.Series
+		PostCount int64 `json:"post_count"`
+	}
+
+	var series []models.Series
+	if err := h.db.Preload("Author").Order("created_at DESC").Find(&series).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch series",
+		})
+		return
+	}
+
+	var rows []struct {
+		SeriesID uuid.UUID
+		Count    int64
+	}
+	if err := h.db.Model(&models.SeriesPost{}).
+		Select("series_posts.series_id, COUNT(*) AS count").
+		Joins("JOIN posts ON posts.id = series_posts.post_id AND posts.deleted_at IS NULL").
+		Where("posts.status = ?", models.PostStatusPublished).
+		Scopes(listedPosts).
+		Group("series_posts.series_id").
+		Scan(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to count series posts",
+		})
+		return
+	}
+	counts := make(map[uuid.UUID]int64, len(rows))
+	for _, row := range rows {
+		counts[row.SeriesID] = row.Count
+	}
+
+	summaries := make([]seriesSummary, 0, len(series))
+	for _, s := range series {
+		summaries = append(summaries, seriesSummary{Series: s, PostCount: counts[s.ID]})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"series": summaries,
+	})
+}
+
+func (h *SeriesHandler) GetSeries(c *gin.Context) {
+	id := c.Param("id")
+
+	query := h.db.Preload("Author")
+	if seriesUUID, err := uuid.Parse(id); err == nil {
+		query = query.Where("id = ?", seriesUUID)
+	} else {
+		query = query.Where("slug = ?", id)
+	}
+
+	var series models.Series
+	if err := query.First(&series).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Series not found",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch series",
+			})
+		}
+		return
+	}
+
+	posts, err := publishedSeriesPosts(h.db, series.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch series posts",
+		})
+		return
+	}
+
+	listed := make([]*models.Post, len(posts))
+	for i := range posts {
+		listed[i] = &posts[i]
+	}
+	redactListed(c, listed)
+
+	c.JSON(http.StatusOK, gin.H{
+		"series": series,
+		"posts":  posts,
+	})
+}
+
+func (h *SeriesHandler) CreateSeries(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	var req CreateSeriesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	slug := generateSlug(req.Title)
+	var existing models.Series
+	if err := h.db.Unscoped().Where("slug = ?", slug).First(&existing).Error; err == nil || slug == "" {
+		slug = slug + "-" + uuid.New().String()[:8]
+	}
+
+	series := models.Series{
+		Title:       req.Title,
+		Slug:        slug,
+		Description: req.Description,
+		AuthorID:    userID,
+	}
+
+	if err := h.db.Create(&series).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to create series",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"series": series,
+	})
+}
+
+func (h *SeriesHandler) UpdateSeries(c *gin.Context) {
+	series, ok := h.loadOwnedSeries(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateSeriesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request data",
+		})
+		return
+	}
+
+	updates := make(map[string]interface{})
+	if req.Title != "" {
+		updates["title"] = req.Title
+	}
+	if req.Description != "" {
+		updates["description"] = req.Description
+	}
+
+	if err := h.db.Model(series).Updates(updates).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to update series",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"series": series,
+	})
+}
+
+func (h *SeriesHandler) DeleteSeries(c *gin.Context) {
+	series, ok := h.loadOwnedSeries(c)
+	if !ok {
+		return
+	}
+
+	if err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("series_id = ?", series.ID).Delete(&models.SeriesPost{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(series).Error
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to delete series",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Series deleted successfully",
+	})
+}
+
+// SetSeriesPosts replaces the membership of a series with post_ids, in order.
+// It is used both to add or remove posts and to reorder them.
+func (h *SeriesHandler) SetSeriesPosts(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	series, ok := h.loadOwnedSeries(c)
+	if !ok {
+		return
+	}
+
+	var req SetSeriesPostsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	seen := make(map[uuid.UUID]bool, len(req.PostIDs))
+	for _, postID := range req.PostIDs {
+		if seen[postID] {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Duplicate post ID: " + postID.String(),
+			})
+			return
+		}
+		seen[postID] = true
+	}
+
+	if len(req.PostIDs) > 0 {
+		var editable int64
+		if err := h.db.Model(&models.Post{}).
+			Scopes(canEditPost(userID)).
+			Where("posts.id IN ?", req.PostIDs).
+			Count(&editable).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to verify posts",
+			})
+			return
+		}
+		if int(editable) != len(req.PostIDs) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "All posts must exist and be editable by you",
+			})
+			return
+		}
+
+		var elsewhere int64
+		h.db.Model(&models.SeriesPost{}).
+			Where("post_id IN ? AND series_id <> ?", req.PostIDs, series.ID).
+			Count(&elsewhere)
+		if elsewhere > 0 {
+			c.JSON(http.StatusConflict, gin.H{
+				"error": "A post can only belong to one series",
+			})
+			return
+		}
+	}
+
+	if err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("series_id = ?", series.ID).Delete(&models.SeriesPost{}).Error; err != nil {
+			return err
+		}
+		for i, postID := range req.PostIDs {
+			entry := models.SeriesPost{
+				SeriesID: series.ID,
+				PostID:   postID,
+				Position: i + 1,
+			}
+			if err := tx.Create(&entry).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to update series posts",
+		})
+		return
+	}
+
+	var entries []models.SeriesPost
+	if err := h.db.Preload("Post").
+		Where("series_id = ?", series.ID).
+		Order("position ASC").
+		Find(&entries).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to load series posts",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"series":  series,
+		"entries": entries,
+	})
+}
+
+func (h *SeriesHandler) loadOwnedSeries(c *gin.Context) (*models.Series, bool) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return nil, false
+	}
+
+	seriesUUID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid series ID",
+		})
+		return nil, false
+	}
+
+	var series models.Series
+	if err := h.db.Where("id = ? AND author_id = ?", seriesUUID, userID).First(&series).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Series not found or not authorized",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch series",
+			})
+		}
+		return nil, false
+	}
+
+	return &series, true
+}
+
+// publishedSeriesPosts returns the published, listed posts of a series in
+// order.
+func publishedSeriesPosts(db *gorm.DB, seriesID uuid.UUID) ([]models.Post, error) {
+	var posts []models.Post
+	err := db.Model(&models.Post{}).
+		Joins("JOIN series_posts ON series_posts.post_id = posts.id").
+		Where("series_posts.series_id = ? AND posts.status = ?", seriesID, models.PostStatusPublished).
+		Scopes(listedPosts).
+		Order("series_posts.position ASC").
+		Find(&posts).Error
+	return posts, err
+}
+
+// seriesNavigation returns the series info for a post, or nil when the post
+// is not part of a series or is not itself publicly listed in it.
+func seriesNavigation(db *gorm.DB, postID uuid.UUID) *SeriesNavigation {
+	var entry models.SeriesPost
+	if err := db.Where("post_id = ?", postID).First(&entry).Error; err != nil {
+		return nil
+	}
+
+	var series models.Series
+	if err := db.First(&series, "id = ?", entry.SeriesID).Error; err != nil {
+		return nil
+	}
+
+	posts, err := publishedSeriesPosts(db, series.ID)
+	if err != nil {
+		return nil
+	}
+
+	nav := &SeriesNavigation{
+		ID:    series.ID,
+		Title: series.Title,
+		Slug:  series.Slug,
+		Total: len(posts),
+	}
+
+	for i, p := range posts {
+		if p.ID != postID {
+			continue
+		}
+		nav.Position = i + 1
+		if i > 0 {
+			nav.Previous = &SeriesLink{ID: posts[i-1].ID, Title: posts[i-1].Title, Slug: posts[i-1].Slug}
+		}
+		if i < len(posts)-1 {
+			nav.Next = &SeriesLink{ID: posts[i+1].ID, Title: posts[i+1].Title, Slug: posts[i+1].Slug}
+		}
+		return nav
+	}
+
+	return nil
+}
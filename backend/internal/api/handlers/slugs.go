@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/yairfalse/modern-cloud-app/backend/internal/database/models"
+)
+
+var (
+	errInvalidSlug = errors.New("invalid slug")
+	errSlugTaken   = errors.New("slug already in use")
+)
+
+// slugTaken reports whether slug is used in locale by a post other than
+// postID, either as its current slug or in its slug history. Soft-deleted
+// posts still hold their slug because the unique index covers them.
+func slugTaken(db *gorm.DB, locale, slug string, postID uuid.UUID) (bool, error) {
+	var count int64
+	if err := db.Unscoped().Model(&models.Post{}).
+		Where("locale = ? AND slug = ? AND id <> ?", locale, slug, postID).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+	if count > 0 {
+		return true, nil
+	}
+
+	if err := db.Model(&models.PostSlug{}).
+		Where("locale = ? AND slug = ? AND post_id <> ?", locale, slug, postID).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// resolveSlug returns the slug to store for postID in locale. A custom slug is
+// normalized and must be free; a slug generated from the title gets a random
+// suffix when it collides.
+func resolveSlug(db *gorm.DB, locale, title, custom string, postID uuid.UUID) (string, error) {
+	if custom != "" {
+		slug := generateSlug(custom)
+		if slug == "" {
+			return "", errInvalidSlug
+		}
+		taken, err := slugTaken(db, locale, slug, postID)
+		if err != nil {
+			return "", err
+		}
+		if taken {
+			return "", errSlugTaken
+		}
+		return slug, nil
+	}
+
+	base := generateSlug(title)
+	if base == "" {
+		base = "post"
+	}
+
+	slug := base
+	for {
+		taken, err := slugTaken(db, locale, slug, postID)
+		if err != nil {
+			return "", err
+		}
+		if !taken {
+			return slug, nil
+		}
+		slug = base + "-" + uuid.New().String()[:8]
+	}
+}
+
+// changeSlug moves post to newSlug, keeping the old slug in the post's
+// history. A slug the post is returning to is removed from its history.
+func changeSlug(tx *gorm.DB, post *models.Post, newSlug string) error {
+	if post.Slug == newSlug {
+		return nil
+	}
+
+	if err := tx.Where("post_id = ? AND locale = ? AND slug = ?", post.ID, post.Locale, newSlug).
+		Delete(&models.PostSlug{}).Error; err != nil {
+		return err
+	}
+
+	var existing int64
+	if err := tx.Model(&models.PostSlug{}).
+		Where("locale = ? AND slug = ?", post.Locale, post.Slug).
+		Count(&existing).Error; err != nil {
+		return err
+	}
+	if existing == 0 {
+		history := models.PostSlug{
+			PostID: post.ID,
+			Locale: post.Locale,
+			Slug:   post.Slug,
+		}
+		if err := tx.Create(&history).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
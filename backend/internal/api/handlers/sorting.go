@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/yairfalse/modern-cloud-app/backend/internal/database/models"
+)
+
+var errInvalidCursor = errors.New("invalid cursor")
+
+// postSort describes how a sort mode orders posts. Every mode breaks ties on
+// posts.id so keyset pagination has a total order.
+type postSort struct {
+	expr  string
+	desc  bool
+	value func(p *models.Post) string
+}
+
+func timeValue(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+func floatValue(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+var postSorts = map[string]postSort{
+	"newest": {
+		expr:  "posts.created_at",
+		desc:  true,
+		value: func(p *models.Post) string { return timeValue(p.CreatedAt) },
+	},
+	"oldest": {
+		expr:  "posts.created_at",
+		desc:  false,
+		value: func(p *models.Post) string { return timeValue(p.CreatedAt) },
+	},
+	"published": {
+		expr: "COALESCE(posts.published_at, posts.created_at)",
+		desc: true,
+		value: func(p *models.Post) string {
+			if p.PublishedAt != nil {
+				return timeValue(*p.PublishedAt)
+			}
+			return timeValue(p.CreatedAt)
+		},
+	},
+	"popular": {
+		expr:  "posts.popularity_score",
+		desc:  true,
+		value: func(p *models.Post) string { return floatValue(p.PopularityScore) },
+	},
+	"trending": {
+		expr:  "posts.trending_score",
+		desc:  true,
+		value: func(p *models.Post) string { return floatValue(p.TrendingScore) },
+	},
+	"discussed": {
+		expr:  "posts.comment_count",
+		desc:  true,
+		value: func(p *models.Post) string { return strconv.Itoa(p.CommentCount) },
+	},
+}
+
+// postCursor marks the last post of a page. It is bound to the sort mode so
+// a cursor cannot be replayed against a different ordering.
+type postCursor struct {
+	Sort  string    `json:"s"`
+	Value string    `json:"v"`
+	ID    uuid.UUID `json:"id"`
+}
+
+func encodeCursor(sortName string, p *models.Post) string {
+	b, _ := json.Marshal(postCursor{
+		Sort:  sortName,
+		Value: postSorts[sortName].value(p),
+		ID:    p.ID,
+	})
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeCursor(sortName, encoded string) (*postCursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+
+	var cursor postCursor
+	if err := json.Unmarshal(b, &cursor); err != nil || cursor.Sort != sortName {
+		return nil, errInvalidCursor
+	}
+	return &cursor, nil
+}
+
+// orderPosts applies the sort mode and, when a cursor is given, restricts the
+// query to rows strictly after it.
+func orderPosts(db *gorm.DB, sortName string, cursor *postCursor) *gorm.DB {
+	s := postSorts[sortName]
+
+	direction, comparison := "ASC", ">"
+	if s.desc {
+		direction, comparison = "DESC", "<"
+	}
+
+	if cursor != nil {
+		db = db.Where("("+s.expr+" "+comparison+" ?) OR ("+s.expr+" = ? AND posts.id "+comparison+" ?)",
+			cursor.Value, cursor.Value, cursor.ID)
+	}
+
+	return db.Order(s.expr + " " + direction).Order("posts.id " + direction)
+}
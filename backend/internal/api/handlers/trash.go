@@ -0,0 +1,315 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/yairfalse/modern-cloud-app/backend/internal/api/middleware"
+	"github.com/yairfalse/modern-cloud-app/backend/internal/config"
+	"github.com/yairfalse/modern-cloud-app/backend/internal/database/models"
+	"github.com/yairfalse/modern-cloud-app/backend/internal/related"
+	"github.com/yairfalse/modern-cloud-app/backend/internal/trash"
+)
+
+type TrashHandler struct {
+	db        *gorm.DB
+	related   *related.Engine
+	retention time.Duration
+}
+
+type TrashQuery struct {
+	Page  int `form:"page,default=1"`
+	Limit int `form:"limit,default=20"`
+}
+
+func NewTrashHandler(db *gorm.DB, relatedEngine *related.Engine, cfg config.TrashConfig) *TrashHandler {
+	return &TrashHandler{
+		db:        db,
+		related:   relatedEngine,
+		retention: cfg.Retention,
+	}
+}
+
+// GetTrash lists the caller's deleted posts and comments with the time each
+// will be purged.
+func (h *TrashHandler) GetTrash(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	var query TrashQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid query parameters",
+		})
+		return
+	}
+	if query.Limit > 100 {
+		query.Limit = 100
+	}
+	if query.Limit < 1 {
+		query.Limit = 20
+	}
+	if query.Page < 1 {
+		query.Page = 1
+	}
+	offset := (query.Page - 1) * query.Limit
+
+	postsQuery := h.db.Unscoped().Model(&models.Post{}).
+		Scopes(ownsPost(userID)).
+		Where("posts.deleted_at IS NOT NULL")
+	var postTotal int64
+	postsQuery.Count(&postTotal)
+
+	var posts []models.Post
+	if err := postsQuery.Order("posts.deleted_at DESC").
+		Offset(offset).Limit(query.Limit).
+		Find(&posts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch deleted posts",
+		})
+		return
+	}
+
+	commentsQuery := h.db.Unscoped().Model(&models.Comment{}).
+		Where("user_id = ? AND deleted_at IS NOT NULL", userID)
+	var commentTotal int64
+	commentsQuery.Count(&commentTotal)
+
+	var comments []models.Comment
+	if err := commentsQuery.Order("deleted_at DESC").
+		Offset(offset).Limit(query.Limit).
+		Find(&comments).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch deleted comments",
+		})
+		return
+	}
+
+	postItems := make([]gin.H, 0, len(posts))
+	for _, post := range posts {
+		postItems = append(postItems, gin.H{
+			"post":       post,
+			"deleted_at": post.DeletedAt.Time,
+			"purge_at":   post.DeletedAt.Time.Add(h.retention),
+		})
+	}
+
+	commentItems := make([]gin.H, 0, len(comments))
+	for _, comment := range comments {
+		commentItems = append(commentItems, gin.H{
+			"comment":    comment,
+			"deleted_at": comment.DeletedAt.Time,
+			"purge_at":   comment.DeletedAt.Time.Add(h.retention),
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"posts":    postItems,
+		"comments": commentItems,
+		"pagination": gin.H{
+			"page":           query.Page,
+			"limit":          query.Limit,
+			"total_posts":    postTotal,
+			"total_comments": commentTotal,
+		},
+	})
+}
+
+// RestorePost takes a post out of the trash. Its counters are recomputed
+// from the rows that are live now.
+func (h *TrashHandler) RestorePost(c *gin.Context) {
+	post, ok := h.loadDeletedPost(c)
+	if !ok {
+		return
+	}
+
+	if err := h.db.Transaction(func(tx *gorm.DB) error {
+		return trash.RestorePost(tx, post.ID)
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to restore post",
+		})
+		return
+	}
+
+	if err := h.db.Scopes(preloadByline, preloadTaxonomy).First(post, "id = ?", post.ID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to load restored post",
+		})
+		return
+	}
+
+	h.related.Enqueue(post.ID)
+
+	c.Header("ETag", versionETag(post.Version))
+	c.JSON(http.StatusOK, gin.H{
+		"post": post,
+	})
+}
+
+// RestoreComment takes a comment out of the trash and counts it on its post
+// again. The post itself must not be in the trash.
+func (h *TrashHandler) RestoreComment(c *gin.Context) {
+	comment, ok := h.loadDeletedComment(c)
+	if !ok {
+		return
+	}
+
+	var live int64
+	h.db.Model(&models.Post{}).Where("id = ?", comment.PostID).Count(&live)
+	if live == 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "The post this comment belongs to is deleted; restore it first",
+		})
+		return
+	}
+
+	if err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Model(comment).Update("deleted_at", nil).Error; err != nil {
+			return err
+		}
+		if comment.Held {
+			return nil
+		}
+		return tx.Model(&models.Post{}).Where("id = ?", comment.PostID).
+			UpdateColumn("comment_count", gorm.Expr("comment_count + ?", 1)).Error
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to restore comment",
+		})
+		return
+	}
+
+	if err := h.db.Preload("User").First(comment, "id = ?", comment.ID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to load restored comment",
+		})
+		return
+	}
+
+	c.Header("ETag", versionETag(comment.Version))
+	c.JSON(http.StatusOK, gin.H{
+		"comment": comment,
+	})
+}
+
+// PurgePost permanently deletes a post that is in the trash.
+func (h *TrashHandler) PurgePost(c *gin.Context) {
+	post, ok := h.loadDeletedPost(c)
+	if !ok {
+		return
+	}
+
+	if err := h.db.Transaction(func(tx *gorm.DB) error {
+		return trash.PurgePost(tx, post.ID)
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to purge post",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Post permanently deleted",
+	})
+}
+
+// PurgeComment permanently deletes a comment that is in the trash.
+func (h *TrashHandler) PurgeComment(c *gin.Context) {
+	comment, ok := h.loadDeletedComment(c)
+	if !ok {
+		return
+	}
+
+	if err := h.db.Transaction(func(tx *gorm.DB) error {
+		return trash.PurgeComment(tx, comment)
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to purge comment",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Comment permanently deleted",
+	})
+}
+
+func (h *TrashHandler) loadDeletedPost(c *gin.Context) (*models.Post, bool) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return nil, false
+	}
+
+	postUUID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid post ID",
+		})
+		return nil, false
+	}
+
+	var post models.Post
+	if err := h.db.Unscoped().Scopes(ownsPost(userID)).
+		Where("posts.id = ? AND posts.deleted_at IS NOT NULL", postUUID).
+		First(&post).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Post not found in trash",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch post",
+			})
+		}
+		return nil, false
+	}
+	return &post, true
+}
+
+func (h *TrashHandler) loadDeletedComment(c *gin.Context) (*models.Comment, bool) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return nil, false
+	}
+
+	commentUUID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid comment ID",
+		})
+		return nil, false
+	}
+
+	var comment models.Comment
+	if err := h.db.Unscoped().
+		Where("id = ? AND user_id = ? AND deleted_at IS NOT NULL", commentUUID, userID).
+		First(&comment).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Comment not found in trash",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch comment",
+			})
+		}
+		return nil, false
+	}
+	return &comment, true
+}
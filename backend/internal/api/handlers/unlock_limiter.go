@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"sync"
+	"time"
+)
+
+// unlockLimiter slows down password guessing on protected posts. Each
+// client gets a number of failed attempts per post; once they are used up
+// the client is locked out of that post, for twice as long each time.
+type unlockLimiter struct {
+	maxAttempts int
+	lockout     time.Duration
+
+	mu      sync.Mutex
+	clients map[string]*unlockAttempts
+	pruneAt int
+}
+
+type unlockAttempts struct {
+	failures    int
+	lockouts    int
+	lockedUntil time.Time
+	lastFailure time.Time
+}
+
+const (
+	// maxUnlockLockout caps the doubling lockout.
+	maxUnlockLockout = 24 * time.Hour
+	// minUnlockPrune is the number of tracked clients below which stale
+	// ones are not pruned.
+	minUnlockPrune = 1024
+)
+
+func newUnlockLimiter(maxAttempts int, lockout time.Duration) *unlockLimiter {
+	if maxAttempts < 1 {
+		maxAttempts = 5
+	}
+	if lockout <= 0 {
+		lockout = 15 * time.Minute
+	}
+	return &unlockLimiter{
+		maxAttempts: maxAttempts,
+		lockout:     lockout,
+		clients:     make(map[string]*unlockAttempts),
+		pruneAt:     minUnlockPrune,
+	}
+}
+
+// wait returns how long key must wait before trying again, or zero.
+func (l *unlockLimiter) wait(key string, now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if a, ok := l.clients[key]; ok && now.Before(a.lockedUntil) {
+		return a.lockedUntil.Sub(now)
+	}
+	return 0
+}
+
+// fail records a wrong password for key and locks it out once it has used up
+// its attempts.
+func (l *unlockLimiter) fail(key string, now time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	a, ok := l.clients[key]
+	if !ok {
+		if len(l.clients) >= l.pruneAt {
+			l.prune(now)
+		}
+		a = &unlockAttempts{}
+		l.clients[key] = a
+	} else if now.Sub(a.lastFailure) > l.lockout {
+		// Occasional mistakes spread out over time do not add up.
+		a.failures = 0
+	}
+	a.lastFailure = now
+	a.failures++
+	if a.failures >= l.maxAttempts {
+		lockout := l.lockout
+		for i := 0; i < a.lockouts && lockout < maxUnlockLockout; i++ {
+			lockout *= 2
+		}
+		a.lockedUntil = now.Add(min(lockout, maxUnlockLockout))
+		a.lockouts++
+		a.failures = 0
+	}
+}
+
+// succeed forgets the failures of key.
+func (l *unlockLimiter) succeed(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.clients, key)
+}
+
+// prune drops clients that are neither locked out nor have recent failures,
+// and sets the size at which to prune next so that pruning stays cheap while
+// many clients are locked out. The caller must hold l.mu.
+func (l *unlockLimiter) prune(now time.Time) {
+	for key, a := range l.clients {
+		if now.After(a.lockedUntil) && now.Sub(a.lastFailure) > maxUnlockLockout {
+			delete(l.clients, key)
+		}
+	}
+	l.pruneAt = max(minUnlockPrune, 2*len(l.clients))
+}
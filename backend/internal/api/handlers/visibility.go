@@ -0,0 +1,249 @@
+package handlers
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/yairfalse/modern-cloud-app/backend/internal/api/middleware"
+	"github.com/yairfalse/modern-cloud-app/backend/internal/database/models"
+)
+
+// unlockTokenHeader carries the token returned by UnlockPost. It can also be
+// passed as the unlock_token query parameter.
+const unlockTokenHeader = "X-Unlock-Token"
+
+var (
+	errInvalidVisibility = errors.New("invalid visibility")
+	errPasswordRequired  = errors.New("password required for password-protected posts")
+)
+
+var listedVisibilities = []models.PostVisibility{
+	models.PostVisibilityPublic,
+	models.PostVisibilityMembers,
+	models.PostVisibilityPassword,
+}
+
+type UnlockPostRequest struct {
+	Password string `json:"password" binding:"required"`
+}
+
+// listedPosts limits a posts query to posts that may appear in lists and
+// feeds, which leaves out unlisted and private posts.
+func listedPosts(db *gorm.DB) *gorm.DB {
+	return db.Where("posts.visibility IN ?", listedVisibilities)
+}
+
+// visibleTo limits a posts query to posts the viewer may open at all, as
+// applyVisibility decides: private posts only show up for their editors.
+func visibleTo(viewerID uuid.UUID) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("posts.visibility <> ? OR posts.author_id = ? OR EXISTS (SELECT 1 FROM post_collaborators pc WHERE pc.post_id = posts.id AND pc.user_id = ? AND pc.status = ?)",
+			models.PostVisibilityPrivate, viewerID, viewerID, models.CollaboratorStatusAccepted)
+	}
+}
+
+// withholdContent strips everything but the teaser from a post the viewer may
+// know about but not read. A generated excerpt quotes the content, so it is
+// no teaser for a password-protected post.
+func withholdContent(post *models.Post) {
+	post.Content = ""
+	post.ContentHTML = ""
+	post.TOC = nil
+	post.Comments = nil
+	post.Locked = true
+	if post.Visibility == models.PostVisibilityPassword && post.ExcerptGenerated {
+		post.Excerpt = ""
+	}
+}
+
+// redactListed withholds content the viewer cannot read from posts shown in a
+// list. Password-protected posts are always locked here since they are
+// unlocked one at a time.
+func redactListed(c *gin.Context, posts []*models.Post) {
+	_, member := middleware.GetUserID(c)
+	for _, post := range posts {
+		switch post.Visibility {
+		case models.PostVisibilityPassword:
+			withholdContent(post)
+		case models.PostVisibilityMembers:
+			if !member {
+				withholdContent(post)
+			}
+		}
+	}
+}
+
+// applyVisibility decides what the viewer may see of a single post. It
+// returns false when the post must be reported as not found, and otherwise
+// withholds the content if the viewer has not unlocked it.
+func (h *PostHandler) applyVisibility(c *gin.Context, post *models.Post) bool {
+	viewerID, member := middleware.GetUserID(c)
+
+	switch post.Visibility {
+	case models.PostVisibilityPrivate:
+		return member && h.canEdit(viewerID, post.ID)
+	case models.PostVisibilityMembers:
+		if !member {
+			withholdContent(post)
+		}
+	case models.PostVisibilityPassword:
+		if member && h.canEdit(viewerID, post.ID) {
+			return true
+		}
+		if !h.unlocked(c, post) {
+			withholdContent(post)
+		}
+	}
+	return true
+}
+
+func (h *PostHandler) canEdit(userID, postID uuid.UUID) bool {
+	var count int64
+	h.db.Model(&models.Post{}).Scopes(canEditPost(userID)).Where("posts.id = ?", postID).Count(&count)
+	return count > 0
+}
+
+func (h *PostHandler) unlocked(c *gin.Context, post *models.Post) bool {
+	token := c.GetHeader(unlockTokenHeader)
+	if token == "" {
+		token = c.Query("unlock_token")
+	}
+	if token == "" {
+		return false
+	}
+
+	claims, err := h.jwtManager.ValidateUnlockToken(token)
+	if err != nil {
+		return false
+	}
+	return claims.PostID == post.ID && claims.Stamp == post.PasswordStamp()
+}
+
+// UnlockPost exchanges the password of a password-protected post for a
+// short-lived token that grants read access to it. Clients that keep giving
+// wrong passwords are locked out of the post for a while.
+func (h *PostHandler) UnlockPost(c *gin.Context) {
+	postUUID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid post ID",
+		})
+		return
+	}
+
+	var req UnlockPostRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	attemptKey := postUUID.String() + ":" + c.ClientIP()
+	if wait := h.unlocks.wait(attemptKey, time.Now()); wait > 0 {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"error": "Too many incorrect passwords, try again later",
+		})
+		return
+	}
+
+	var post models.Post
+	if err := h.db.Select("id", "visibility", "password_hash").
+		Where("id = ? AND status = ? AND visibility = ?", postUUID, models.PostStatusPublished, models.PostVisibilityPassword).
+		First(&post).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Post not found",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch post",
+			})
+		}
+		return
+	}
+
+	if !post.CheckPassword(req.Password) {
+		h.unlocks.fail(attemptKey, time.Now())
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Incorrect password",
+		})
+		return
+	}
+
+	h.unlocks.succeed(attemptKey)
+
+	expiresAt := time.Now().UTC().Add(h.jwtConfig.UnlockTTL)
+	token, err := h.jwtManager.GenerateUnlockToken(post.ID, post.PasswordStamp(), expiresAt)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to generate unlock token",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"token":      token,
+		"expires_at": expiresAt,
+	})
+}
+
+func respondVisibilityError(c *gin.Context, err error) {
+	switch err {
+	case errInvalidVisibility:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid visibility, must be one of public, unlisted, members, private, password",
+		})
+	case errPasswordRequired:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "A password is required for password-protected posts",
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to set post password",
+		})
+	}
+}
+
+// visibilityUpdate validates a requested visibility and password and returns
+// the columns to store. A password is required when switching a post to
+// password protection, and is cleared when switching away from it.
+func visibilityUpdate(current models.PostVisibility, hasPassword bool, visibility, password string) (map[string]interface{}, error) {
+	target := current
+	if visibility != "" {
+		target = models.PostVisibility(visibility)
+		if !target.Valid() {
+			return nil, errInvalidVisibility
+		}
+	}
+
+	updates := make(map[string]interface{})
+	if target != current {
+		updates["visibility"] = target
+	}
+
+	switch {
+	case target != models.PostVisibilityPassword:
+		if hasPassword {
+			updates["password_hash"] = ""
+		}
+	case password != "":
+		var post models.Post
+		if err := post.SetPassword(password); err != nil {
+			return nil, err
+		}
+		updates["password_hash"] = post.PasswordHash
+	case !hasPassword:
+		return nil, errPasswordRequired
+	}
+	return updates, nil
+}
@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/yairfalse/modern-cloud-app/backend/internal/database/models"
+)
+
+// RequireRole must run after RequireAuth. Roles are read from the database so
+// that a changed role takes effect without waiting for tokens to expire.
+func RequireRole(db *gorm.DB, roles ...models.UserRole) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := GetUserID(c)
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "User not authenticated",
+			})
+			c.Abort()
+			return
+		}
+
+		var user models.User
+		if err := db.Select("id", "role", "is_active").First(&user, "id = ?", userID).Error; err != nil || !user.IsActive {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "Insufficient permissions",
+			})
+			c.Abort()
+			return
+		}
+
+		for _, role := range roles {
+			if user.Role == role {
+				c.Set("user_role", user.Role)
+				c.Next()
+				return
+			}
+		}
+
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Insufficient permissions",
+		})
+		c.Abort()
+	}
+}
+
+// IsEditor reports whether the authenticated user has an editorial role.
+func IsEditor(c *gin.Context, db *gorm.DB) bool {
+	role, ok := GetUserRole(c, db)
+	return ok && (role == models.UserRoleEditor || role == models.UserRoleAdmin)
+}
+
+// GetUserRole returns the authenticated user's role, reading it from the
+// database once per request.
+func GetUserRole(c *gin.Context, db *gorm.DB) (models.UserRole, bool) {
+	if value, exists := c.Get("user_role"); exists {
+		if role, ok := value.(models.UserRole); ok {
+			return role, true
+		}
+	}
+
+	userID, exists := GetUserID(c)
+	if !exists {
+		return "", false
+	}
+
+	var user models.User
+	if err := db.Select("id", "role").First(&user, "id = ?", userID).Error; err != nil {
+		return "", false
+	}
+
+	c.Set("user_role", user.Role)
+	return user.Role, true
+}
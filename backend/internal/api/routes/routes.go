@@ -4,13 +4,24 @@ import (
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 
+	"github.com/yairfalse/modern-cloud-app/backend/internal/analytics"
 	"github.com/yairfalse/modern-cloud-app/backend/internal/api/handlers"
 	"github.com/yairfalse/modern-cloud-app/backend/internal/api/middleware"
+	"github.com/yairfalse/modern-cloud-app/backend/internal/bulk"
 	"github.com/yairfalse/modern-cloud-app/backend/internal/config"
+	"github.com/yairfalse/modern-cloud-app/backend/internal/database/models"
+	"github.com/yairfalse/modern-cloud-app/backend/internal/duplicates"
+	"github.com/yairfalse/modern-cloud-app/backend/internal/jobs"
+	"github.com/yairfalse/modern-cloud-app/backend/internal/linkgraph"
+	"github.com/yairfalse/modern-cloud-app/backend/internal/ranking"
+	"github.com/yairfalse/modern-cloud-app/backend/internal/related"
+	"github.com/yairfalse/modern-cloud-app/backend/internal/scheduling"
+	"github.com/yairfalse/modern-cloud-app/backend/internal/trash"
+	"github.com/yairfalse/modern-cloud-app/backend/internal/views"
 	"github.com/yairfalse/modern-cloud-app/backend/pkg/auth"
 )
 
-func Setup(router *gin.Engine, db *gorm.DB, cfg *config.Config) {
+func Setup(router *gin.Engine, db *gorm.DB, cfg *config.Config, runner *jobs.Runner) {
 	jwtManager := auth.NewJWTManager(
 		cfg.JWT.Secret,
 		cfg.JWT.AccessTokenTTL,
@@ -20,14 +31,61 @@ func Setup(router *gin.Engine, db *gorm.DB, cfg *config.Config) {
 	authMiddleware := middleware.NewAuthMiddleware(jwtManager)
 
 	authHandler := handlers.NewAuthHandler(db, jwtManager)
-	postHandler := handlers.NewPostHandler(db)
-	commentHandler := handlers.NewCommentHandler(db)
+	relatedEngine := related.NewEngine(db, cfg.Related)
+	runner.Add(relatedEngine)
+
+	viewCounter := views.NewCounter(db, cfg.Views)
+	runner.Add(viewCounter)
+
+	linkGraph := linkgraph.NewGraph(cfg.Links)
+	detector := duplicates.NewDetector(cfg.Duplicates)
+
+	postHandler := handlers.NewPostHandler(db, jwtManager, cfg.JWT, relatedEngine, viewCounter, cfg.I18n, linkGraph, detector, cfg.SEO)
+	commentHandler := handlers.NewCommentHandler(db, detector)
+	collaboratorHandler := handlers.NewCollaboratorHandler(db)
+	seriesHandler := handlers.NewSeriesHandler(db)
+	categoryHandler := handlers.NewCategoryHandler(db)
+	analyticsHandler := handlers.NewAnalyticsHandler(db)
+	bookmarkHandler := handlers.NewBookmarkHandler(db)
+	redirectHandler := handlers.NewRedirectHandler(db)
+	trashHandler := handlers.NewTrashHandler(db, relatedEngine, cfg.Trash)
+	reviewHandler := handlers.NewReviewHandler(db)
+	annotationHandler := handlers.NewAnnotationHandler(db)
+	autosaveHandler := handlers.NewAutosaveHandler(db)
+	linkHandler := handlers.NewLinkHandler(db)
+	duplicateHandler := handlers.NewDuplicateHandler(db)
+	userHandler := handlers.NewUserHandler(db)
+	runner.Add(trash.NewPurger(db, cfg.Trash))
+
+	bulkProcessor := bulk.NewProcessor(db, cfg.Bulk, relatedEngine)
+	runner.Add(bulkProcessor)
+	bulkHandler := handlers.NewBulkHandler(db, bulkProcessor, cfg.Bulk)
+
+	runner.Add(analytics.NewRollup(db, cfg.Analytics))
+	runner.Add(ranking.NewScorer(db, cfg.Ranking))
+	runner.Add(scheduling.NewPublisher(db, cfg.Scheduling, relatedEngine))
 
 	api := router.Group("/api/v1")
 
 	setupAuthRoutes(api, authHandler, authMiddleware)
 	setupPostRoutes(api, postHandler, authMiddleware)
-	setupCommentRoutes(api, commentHandler, authMiddleware)
+	setupCommentRoutes(api, commentHandler, authMiddleware, db)
+	setupPreviewRoutes(api, postHandler)
+	setupRedirectRoutes(api, redirectHandler)
+	setupCollaboratorRoutes(api, collaboratorHandler, authMiddleware)
+	setupSeriesRoutes(api, seriesHandler, authMiddleware)
+	setupCategoryRoutes(api, categoryHandler, authMiddleware, db)
+	setupAnalyticsRoutes(api, analyticsHandler, authMiddleware)
+	setupBookmarkRoutes(api, bookmarkHandler, authMiddleware)
+	setupBulkRoutes(api, bulkHandler, authMiddleware, db)
+	setupTrashRoutes(api, trashHandler, authMiddleware)
+	setupReviewRoutes(api, reviewHandler, authMiddleware, db)
+	setupAnnotationRoutes(api, annotationHandler, authMiddleware)
+	setupAutosaveRoutes(api, autosaveHandler, authMiddleware)
+	setupLinkRoutes(api, linkHandler, authMiddleware, db)
+	setupDuplicateRoutes(api, duplicateHandler, authMiddleware, db)
+	setupUserRoutes(api, userHandler, authMiddleware, db)
+	setupMeRoutes(api, postHandler, collaboratorHandler, analyticsHandler, bookmarkHandler, authMiddleware)
 }
 
 func setupAuthRoutes(api *gin.RouterGroup, handler *handlers.AuthHandler, authMw *middleware.AuthMiddleware) {
@@ -44,20 +102,184 @@ func setupAuthRoutes(api *gin.RouterGroup, handler *handlers.AuthHandler, authMw
 func setupPostRoutes(api *gin.RouterGroup, handler *handlers.PostHandler, authMw *middleware.AuthMiddleware) {
 	posts := api.Group("/posts")
 	{
-		posts.GET("", handler.GetPosts)
-		posts.GET("/:id", handler.GetPost)
+		posts.GET("", authMw.OptionalAuth(), handler.GetPosts)
+		posts.GET("/:id", authMw.OptionalAuth(), handler.GetPost)
+		posts.GET("/:id/related", handler.GetRelatedPosts)
+		posts.POST("/:id/unlock", handler.UnlockPost)
+		posts.POST("/:id/translations", authMw.RequireAuth(), handler.LinkTranslation)
+		posts.DELETE("/:id/translations", authMw.RequireAuth(), handler.UnlinkTranslation)
 		posts.POST("", authMw.RequireAuth(), handler.CreatePost)
 		posts.PUT("/:id", authMw.RequireAuth(), handler.UpdatePost)
 		posts.DELETE("/:id", authMw.RequireAuth(), handler.DeletePost)
+
+		posts.GET("/:id/preview-links", authMw.RequireAuth(), handler.GetPreviewLinks)
+		posts.POST("/:id/preview-links", authMw.RequireAuth(), handler.CreatePreviewLink)
+		posts.DELETE("/:id/preview-links/:linkId", authMw.RequireAuth(), handler.RevokePreviewLink)
+
+		posts.POST("/import", authMw.RequireAuth(), handler.ImportPosts)
+		posts.GET("/export", authMw.RequireAuth(), handler.ExportPosts)
+		posts.GET("/:id/export", authMw.RequireAuth(), handler.ExportPost)
+	}
+}
+
+func setupCollaboratorRoutes(api *gin.RouterGroup, handler *handlers.CollaboratorHandler, authMw *middleware.AuthMiddleware) {
+	collaborators := api.Group("/posts/:id/collaborators", authMw.RequireAuth())
+	{
+		collaborators.GET("", handler.GetCollaborators)
+		collaborators.POST("", handler.InviteCollaborator)
+		collaborators.POST("/accept", handler.AcceptInvitation)
+		collaborators.POST("/decline", handler.DeclineInvitation)
+		collaborators.PUT("/:userId", handler.UpdateCollaborator)
+		collaborators.DELETE("/:userId", handler.RemoveCollaborator)
+	}
+}
+
+func setupSeriesRoutes(api *gin.RouterGroup, handler *handlers.SeriesHandler, authMw *middleware.AuthMiddleware) {
+	series := api.Group("/series")
+	{
+		series.GET("", handler.GetSeriesList)
+		series.GET("/:id", authMw.OptionalAuth(), handler.GetSeries)
+		series.POST("", authMw.RequireAuth(), handler.CreateSeries)
+		series.PUT("/:id", authMw.RequireAuth(), handler.UpdateSeries)
+		series.DELETE("/:id", authMw.RequireAuth(), handler.DeleteSeries)
+		series.PUT("/:id/posts", authMw.RequireAuth(), handler.SetSeriesPosts)
+	}
+}
+
+func setupCategoryRoutes(api *gin.RouterGroup, handler *handlers.CategoryHandler, authMw *middleware.AuthMiddleware, db *gorm.DB) {
+	requireEditor := middleware.RequireRole(db, models.UserRoleEditor, models.UserRoleAdmin)
+
+	categories := api.Group("/categories")
+	{
+		categories.GET("", handler.GetCategories)
+		categories.GET("/:id", handler.GetCategory)
+		categories.POST("", authMw.RequireAuth(), requireEditor, handler.CreateCategory)
+		categories.PUT("/:id", authMw.RequireAuth(), requireEditor, handler.UpdateCategory)
+		categories.PUT("/:id/move", authMw.RequireAuth(), requireEditor, handler.MoveCategory)
+		categories.DELETE("/:id", authMw.RequireAuth(), requireEditor, handler.DeleteCategory)
+	}
+}
+
+func setupAnalyticsRoutes(api *gin.RouterGroup, handler *handlers.AnalyticsHandler, authMw *middleware.AuthMiddleware) {
+	api.GET("/posts/:id/stats", authMw.RequireAuth(), handler.GetPostStats)
+}
+
+func setupBookmarkRoutes(api *gin.RouterGroup, handler *handlers.BookmarkHandler, authMw *middleware.AuthMiddleware) {
+	posts := api.Group("/posts/:id", authMw.RequireAuth())
+	{
+		posts.PUT("/bookmark", handler.SaveBookmark)
+		posts.DELETE("/bookmark", handler.DeleteBookmark)
+		posts.PUT("/progress", handler.UpdateProgress)
+	}
+}
+
+func setupBulkRoutes(api *gin.RouterGroup, handler *handlers.BulkHandler, authMw *middleware.AuthMiddleware, db *gorm.DB) {
+	bulkOps := api.Group("/posts/bulk", authMw.RequireAuth(), middleware.RequireRole(db, models.UserRoleEditor, models.UserRoleAdmin))
+	{
+		bulkOps.POST("", handler.BulkPosts)
+		bulkOps.GET("/:jobId", handler.GetBulkJob)
+	}
+}
+
+func setupReviewRoutes(api *gin.RouterGroup, handler *handlers.ReviewHandler, authMw *middleware.AuthMiddleware, db *gorm.DB) {
+	requireEditor := middleware.RequireRole(db, models.UserRoleEditor, models.UserRoleAdmin)
+
+	reviews := api.Group("/posts/:id", authMw.RequireAuth())
+	{
+		reviews.GET("/reviews", handler.GetReviews)
+		reviews.POST("/review", handler.SubmitForReview)
+		reviews.POST("/review/withdraw", handler.WithdrawReview)
+		reviews.PUT("/review/reviewer", requireEditor, handler.AssignReviewer)
+		reviews.POST("/review/approve", requireEditor, handler.ApproveReview)
+		reviews.POST("/review/request-changes", requireEditor, handler.RequestChanges)
 	}
+
+	api.GET("/me/reviews", authMw.RequireAuth(), requireEditor, handler.GetReviewQueue)
 }
 
-func setupCommentRoutes(api *gin.RouterGroup, handler *handlers.CommentHandler, authMw *middleware.AuthMiddleware) {
+func setupAnnotationRoutes(api *gin.RouterGroup, handler *handlers.AnnotationHandler, authMw *middleware.AuthMiddleware) {
+	annotations := api.Group("/posts/:id/annotations", authMw.RequireAuth())
+	{
+		annotations.GET("", handler.GetAnnotations)
+		annotations.POST("", handler.CreateAnnotation)
+		annotations.PUT("/:annotationId", handler.UpdateAnnotation)
+		annotations.DELETE("/:annotationId", handler.DeleteAnnotation)
+		annotations.POST("/:annotationId/replies", handler.ReplyToAnnotation)
+		annotations.POST("/:annotationId/resolve", handler.ResolveAnnotation)
+		annotations.POST("/:annotationId/unresolve", handler.UnresolveAnnotation)
+	}
+}
+
+func setupAutosaveRoutes(api *gin.RouterGroup, handler *handlers.AutosaveHandler, authMw *middleware.AuthMiddleware) {
+	autosave := api.Group("/posts/:id/autosave", authMw.RequireAuth())
+	{
+		autosave.GET("", handler.GetAutosave)
+		autosave.PUT("", handler.SaveAutosave)
+		autosave.DELETE("", handler.DeleteAutosave)
+	}
+}
+
+func setupLinkRoutes(api *gin.RouterGroup, handler *handlers.LinkHandler, authMw *middleware.AuthMiddleware, db *gorm.DB) {
+	requireEditor := middleware.RequireRole(db, models.UserRoleEditor, models.UserRoleAdmin)
+
+	api.GET("/posts/:id/backlinks", handler.GetBacklinks)
+	api.GET("/links/report", authMw.RequireAuth(), requireEditor, handler.GetLinkReport)
+}
+
+func setupDuplicateRoutes(api *gin.RouterGroup, handler *handlers.DuplicateHandler, authMw *middleware.AuthMiddleware, db *gorm.DB) {
+	api.GET("/admin/duplicates", authMw.RequireAuth(), middleware.RequireRole(db, models.UserRoleAdmin), handler.GetDuplicateClusters)
+}
+
+func setupUserRoutes(api *gin.RouterGroup, handler *handlers.UserHandler, authMw *middleware.AuthMiddleware, db *gorm.DB) {
+	api.PUT("/admin/users/:id/role", authMw.RequireAuth(), middleware.RequireRole(db, models.UserRoleAdmin), handler.UpdateUserRole)
+}
+
+func setupTrashRoutes(api *gin.RouterGroup, handler *handlers.TrashHandler, authMw *middleware.AuthMiddleware) {
+	api.POST("/posts/:id/restore", authMw.RequireAuth(), handler.RestorePost)
+	api.POST("/comments/:id/restore", authMw.RequireAuth(), handler.RestoreComment)
+
+	trashed := api.Group("/me/trash", authMw.RequireAuth())
+	{
+		trashed.GET("", handler.GetTrash)
+		trashed.DELETE("/posts/:id", handler.PurgePost)
+		trashed.DELETE("/comments/:id", handler.PurgeComment)
+	}
+}
+
+func setupMeRoutes(api *gin.RouterGroup, postHandler *handlers.PostHandler, collaboratorHandler *handlers.CollaboratorHandler, analyticsHandler *handlers.AnalyticsHandler, bookmarkHandler *handlers.BookmarkHandler, authMw *middleware.AuthMiddleware) {
+	me := api.Group("/me", authMw.RequireAuth())
+	{
+		me.GET("/posts", postHandler.GetMyPosts)
+		me.GET("/invitations", collaboratorHandler.GetInvitations)
+		me.GET("/stats", analyticsHandler.GetDashboard)
+		me.GET("/bookmarks", bookmarkHandler.GetBookmarks)
+		me.GET("/bookmark-folders", bookmarkHandler.GetFolders)
+		me.POST("/bookmark-folders", bookmarkHandler.CreateFolder)
+		me.PUT("/bookmark-folders/:id", bookmarkHandler.UpdateFolder)
+		me.DELETE("/bookmark-folders/:id", bookmarkHandler.DeleteFolder)
+	}
+}
+
+func setupPreviewRoutes(api *gin.RouterGroup, handler *handlers.PostHandler) {
+	api.GET("/preview/:token", handler.GetPreview)
+}
+
+func setupRedirectRoutes(api *gin.RouterGroup, handler *handlers.RedirectHandler) {
+	api.GET("/redirects", handler.ResolveLegacyURL)
+}
+
+func setupCommentRoutes(api *gin.RouterGroup, handler *handlers.CommentHandler, authMw *middleware.AuthMiddleware, db *gorm.DB) {
+	requireEditor := middleware.RequireRole(db, models.UserRoleEditor, models.UserRoleAdmin)
+
 	comments := api.Group("/comments")
 	{
 		comments.GET("", handler.GetComments) // Use query param ?post_id=
 		comments.POST("", authMw.RequireAuth(), handler.CreateComment)
 		comments.PUT("/:id", authMw.RequireAuth(), handler.UpdateComment)
 		comments.DELETE("/:id", authMw.RequireAuth(), handler.DeleteComment)
+
+		comments.GET("/held", authMw.RequireAuth(), requireEditor, handler.GetHeldComments)
+		comments.POST("/:id/approve", authMw.RequireAuth(), requireEditor, handler.ApproveComment)
+		comments.POST("/:id/reject", authMw.RequireAuth(), requireEditor, handler.RejectComment)
 	}
 }
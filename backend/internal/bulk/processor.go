@@ -0,0 +1,406 @@
+package bulk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/yairfalse/modern-cloud-app/backend/internal/config"
+	"github.com/yairfalse/modern-cloud-app/backend/internal/database/models"
+	"github.com/yairfalse/modern-cloud-app/backend/internal/related"
+	"github.com/yairfalse/modern-cloud-app/backend/internal/trash"
+	"github.com/yairfalse/modern-cloud-app/backend/pkg/slugify"
+)
+
+const (
+	queueSize           = 64
+	defaultPollInterval = 10 * time.Second
+)
+
+var (
+	errPostNotFound = errors.New("post not found")
+	errNotDeleted   = errors.New("post is not deleted")
+)
+
+// Operation is a single bulk action and its arguments. ActorID is the user
+// the changes are made on behalf of.
+type Operation struct {
+	Action  models.BulkAction
+	Params  models.BulkParams
+	ActorID uuid.UUID
+}
+
+// Validate checks the arguments an action needs before any post is touched.
+func (o Operation) Validate(db *gorm.DB) error {
+	if !o.Action.Valid() {
+		return fmt.Errorf("invalid action %q", o.Action)
+	}
+
+	switch o.Action {
+	case models.BulkActionAddTags, models.BulkActionRemoveTags:
+		if len(cleanTags(o.Params.Tags)) == 0 {
+			return errors.New("tags are required for this action")
+		}
+	case models.BulkActionReassignAuthor:
+		if o.Params.AuthorID == nil {
+			return errors.New("author_id is required for this action")
+		}
+		var count int64
+		db.Model(&models.User{}).Where("id = ?", *o.Params.AuthorID).Count(&count)
+		if count == 0 {
+			return errors.New("author not found")
+		}
+	}
+	return nil
+}
+
+type Processor struct {
+	db           *gorm.DB
+	related      *related.Engine
+	batchSize    int
+	pollInterval time.Duration
+	queue        chan uuid.UUID
+}
+
+func NewProcessor(db *gorm.DB, cfg config.BulkConfig, relatedEngine *related.Engine) *Processor {
+	batchSize := cfg.BatchSize
+	if batchSize < 1 {
+		batchSize = 100
+	}
+	pollInterval := cfg.PollInterval
+	if pollInterval <= 0 {
+		pollInterval = defaultPollInterval
+	}
+	return &Processor{
+		db:           db,
+		related:      relatedEngine,
+		batchSize:    batchSize,
+		pollInterval: pollInterval,
+		queue:        make(chan uuid.UUID, queueSize),
+	}
+}
+
+// Apply runs op over postIDs in a single transaction. Each post is applied
+// in its own savepoint, so a post that fails is reported and skipped while
+// the rest are committed. The returned error is set only if the transaction
+// itself failed, in which case nothing was applied.
+func (p *Processor) Apply(op Operation, postIDs []uuid.UUID) (models.BulkFailures, error) {
+	var failures models.BulkFailures
+	err := p.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		failures, err = p.applyBatch(tx, op, postIDs)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	p.enqueueRelated(op, postIDs, failures)
+	return failures, nil
+}
+
+// Submit stores a job and schedules it for processing.
+func (p *Processor) Submit(job *models.BulkJob) error {
+	job.Status = models.BulkJobStatusPending
+	job.Total = len(job.PostIDs)
+	job.Processed = 0
+	job.Failures = models.BulkFailures{}
+
+	if err := p.db.Create(job).Error; err != nil {
+		return err
+	}
+
+	select {
+	case p.queue <- job.ID:
+	default:
+	}
+	return nil
+}
+
+// Run processes submitted jobs. Jobs left pending or running by a previous
+// process are picked up on each poll.
+func (p *Processor) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.pollInterval)
+	defer ticker.Stop()
+
+	p.resumePending(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case jobID := <-p.queue:
+			p.process(ctx, jobID)
+		case <-ticker.C:
+			p.resumePending(ctx)
+		}
+	}
+}
+
+func (p *Processor) resumePending(ctx context.Context) {
+	var ids []uuid.UUID
+	if err := p.db.Model(&models.BulkJob{}).
+		Where("status IN ?", []models.BulkJobStatus{models.BulkJobStatusPending, models.BulkJobStatusRunning}).
+		Order("created_at ASC").
+		Pluck("id", &ids).Error; err != nil {
+		log.Printf("bulk: failed to list pending jobs: %v", err)
+		return
+	}
+
+	for _, id := range ids {
+		if ctx.Err() != nil {
+			return
+		}
+		p.process(ctx, id)
+	}
+}
+
+func (p *Processor) process(ctx context.Context, jobID uuid.UUID) {
+	var job models.BulkJob
+	if err := p.db.First(&job, "id = ?", jobID).Error; err != nil {
+		log.Printf("bulk: failed to load job %s: %v", jobID, err)
+		return
+	}
+	if job.Status == models.BulkJobStatusCompleted || job.Status == models.BulkJobStatusFailed {
+		return
+	}
+
+	if job.StartedAt == nil {
+		now := time.Now()
+		job.StartedAt = &now
+	}
+	job.Status = models.BulkJobStatusRunning
+	if err := p.db.Model(&job).Updates(map[string]interface{}{
+		"status":     job.Status,
+		"started_at": job.StartedAt,
+	}).Error; err != nil {
+		log.Printf("bulk: failed to start job %s: %v", job.ID, err)
+		return
+	}
+
+	op := Operation{Action: job.Action, Params: job.Params, ActorID: job.CreatedBy}
+	if err := op.Validate(p.db); err != nil {
+		p.finish(&job, models.BulkJobStatusFailed, err.Error())
+		return
+	}
+
+	for job.Processed < len(job.PostIDs) {
+		// Stop between batches on shutdown; the job stays running and is
+		// resumed from Processed on the next start.
+		if ctx.Err() != nil {
+			return
+		}
+
+		end := job.Processed + p.batchSize
+		if end > len(job.PostIDs) {
+			end = len(job.PostIDs)
+		}
+		batch := job.PostIDs[job.Processed:end]
+
+		var failures models.BulkFailures
+		err := p.db.Transaction(func(tx *gorm.DB) error {
+			var err error
+			failures, err = p.applyBatch(tx, op, batch)
+			if err != nil {
+				return err
+			}
+			return tx.Model(&job).Updates(map[string]interface{}{
+				"processed": end,
+				"failures":  append(job.Failures, failures...),
+			}).Error
+		})
+		if err != nil {
+			log.Printf("bulk: job %s failed: %v", job.ID, err)
+			p.finish(&job, models.BulkJobStatusFailed, "internal error while processing posts")
+			return
+		}
+
+		job.Processed = end
+		job.Failures = append(job.Failures, failures...)
+		p.enqueueRelated(op, batch, failures)
+	}
+
+	p.finish(&job, models.BulkJobStatusCompleted, "")
+}
+
+func (p *Processor) finish(job *models.BulkJob, status models.BulkJobStatus, message string) {
+	now := time.Now()
+	if err := p.db.Model(job).Updates(map[string]interface{}{
+		"status":      status,
+		"error":       message,
+		"finished_at": now,
+	}).Error; err != nil {
+		log.Printf("bulk: failed to finish job %s: %v", job.ID, err)
+	}
+}
+
+func (p *Processor) applyBatch(tx *gorm.DB, op Operation, postIDs []uuid.UUID) (models.BulkFailures, error) {
+	var tags []models.Tag
+	if op.Action == models.BulkActionAddTags || op.Action == models.BulkActionRemoveTags {
+		var err error
+		if tags, err = resolveTags(tx, op.Params.Tags, op.Action == models.BulkActionAddTags); err != nil {
+			return nil, err
+		}
+	}
+
+	// Status changes are checked against the actor's current role, which
+	// may have changed since a queued job was submitted.
+	var actor models.User
+	if err := tx.Select("id", "role").First(&actor, "id = ?", op.ActorID).Error; err != nil {
+		return nil, err
+	}
+
+	failures := models.BulkFailures{}
+	for _, id := range postIDs {
+		err := tx.Transaction(func(tx *gorm.DB) error {
+			return applyOne(tx, op, &actor, id, tags)
+		})
+		if err != nil {
+			failures = append(failures, models.BulkFailure{PostID: id, Error: err.Error()})
+		}
+	}
+	return failures, nil
+}
+
+func applyOne(tx *gorm.DB, op Operation, actor *models.User, postID uuid.UUID, tags []models.Tag) error {
+	var post models.Post
+	lookup := tx
+	if op.Action == models.BulkActionRestore {
+		lookup = tx.Unscoped()
+	}
+	if err := lookup.First(&post, "id = ?", postID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errPostNotFound
+		}
+		return err
+	}
+
+	switch op.Action {
+	case models.BulkActionPublish:
+		return changeStatus(tx, &post, models.PostStatusPublished, actor)
+
+	case models.BulkActionArchive:
+		return changeStatus(tx, &post, models.PostStatusArchived, actor)
+
+	case models.BulkActionDelete:
+		return tx.Delete(&post).Error
+
+	case models.BulkActionRestore:
+		if !post.DeletedAt.Valid {
+			return errNotDeleted
+		}
+		return trash.RestorePost(tx, post.ID)
+
+	case models.BulkActionAddTags:
+		return tx.Model(&post).Association("Tags").Append(tags)
+
+	case models.BulkActionRemoveTags:
+		if len(tags) == 0 {
+			return nil
+		}
+		return tx.Model(&post).Association("Tags").Delete(tags)
+
+	case models.BulkActionReassignAuthor:
+		return reassignAuthor(tx, &post, *op.Params.AuthorID)
+	}
+
+	return fmt.Errorf("invalid action %q", op.Action)
+}
+
+// changeStatus moves a post to next under the same rules as a single update:
+// the transition must be allowed, and publishing a post that is in review
+// settles its open review.
+func changeStatus(tx *gorm.DB, post *models.Post, next models.PostStatus, actor *models.User) error {
+	if err := models.CheckStatusChange(tx, actor.Role, post, next); err != nil {
+		return err
+	}
+	if next == post.Status {
+		return nil
+	}
+
+	updates := map[string]interface{}{"status": next}
+	if next == models.PostStatusPublished {
+		if post.PublishedAt == nil {
+			updates["published_at"] = time.Now()
+		}
+		if post.Status == models.PostStatusInReview {
+			editor := actor.Role == models.UserRoleEditor || actor.Role == models.UserRoleAdmin
+			if err := models.ClosePendingReview(tx, post, actor.ID, editor); err != nil {
+				return err
+			}
+		}
+	}
+	return tx.Model(post).Updates(updates).Error
+}
+
+// reassignAuthor moves the post and its owner collaborator row to a new
+// author. An existing collaborator row for the new author is replaced.
+func reassignAuthor(tx *gorm.DB, post *models.Post, authorID uuid.UUID) error {
+	if post.AuthorID == authorID {
+		return nil
+	}
+	if err := models.MoveOwnership(tx, post, authorID); err != nil {
+		return err
+	}
+	return tx.Model(post).Update("author_id", authorID).Error
+}
+
+// resolveTags looks up tags by name, creating missing ones when create is set.
+func resolveTags(tx *gorm.DB, names []string, create bool) ([]models.Tag, error) {
+	var tags []models.Tag
+	for _, name := range cleanTags(names) {
+		slug := slugify.Make(name)
+
+		var tag models.Tag
+		err := tx.Where("slug = ?", slug).First(&tag).Error
+		switch {
+		case err == nil:
+			tags = append(tags, tag)
+		case !errors.Is(err, gorm.ErrRecordNotFound):
+			return nil, err
+		case create:
+			tag = models.Tag{Name: name, Slug: slug}
+			if err := tx.Create(&tag).Error; err != nil {
+				return nil, err
+			}
+			tags = append(tags, tag)
+		}
+	}
+	return tags, nil
+}
+
+func (p *Processor) enqueueRelated(op Operation, postIDs []uuid.UUID, failures models.BulkFailures) {
+	if p.related == nil {
+		return
+	}
+
+	failed := make(map[uuid.UUID]bool, len(failures))
+	for _, f := range failures {
+		failed[f.PostID] = true
+	}
+	for _, id := range postIDs {
+		if !failed[id] {
+			p.related.Enqueue(id)
+		}
+	}
+}
+
+func cleanTags(names []string) []string {
+	seen := make(map[string]bool, len(names))
+	var result []string
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[slugify.Make(name)] {
+			continue
+		}
+		seen[slugify.Make(name)] = true
+		result = append(result, name)
+	}
+	return result
+}
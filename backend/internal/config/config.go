@@ -4,6 +4,7 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -13,6 +14,17 @@ type Config struct {
 	Database    DatabaseConfig
 	JWT         JWTConfig
 	Cache       CacheConfig
+	Related     RelatedConfig
+	Views       ViewsConfig
+	Analytics   AnalyticsConfig
+	Ranking     RankingConfig
+	Bulk        BulkConfig
+	Trash       TrashConfig
+	I18n        I18nConfig
+	Links       LinksConfig
+	Duplicates  DuplicatesConfig
+	SEO         SEOConfig
+	Scheduling  SchedulingConfig
 }
 
 type ServerConfig struct {
@@ -38,6 +50,13 @@ type JWTConfig struct {
 	Secret          string
 	AccessTokenTTL  time.Duration
 	RefreshTokenTTL time.Duration
+	PreviewTTL      time.Duration
+	PreviewMaxTTL   time.Duration
+	UnlockTTL       time.Duration
+	// UnlockMaxAttempts wrong passwords lock a client out of a post for
+	// UnlockLockout, doubling with each further lockout.
+	UnlockMaxAttempts int
+	UnlockLockout     time.Duration
 }
 
 type CacheConfig struct {
@@ -45,6 +64,91 @@ type CacheConfig struct {
 	CleanupInterval   time.Duration
 }
 
+type RelatedConfig struct {
+	RefreshInterval time.Duration
+	MaxResults      int
+}
+
+type ViewsConfig struct {
+	FlushInterval time.Duration
+	DedupeWindow  time.Duration
+}
+
+type AnalyticsConfig struct {
+	RollupInterval time.Duration
+	RawRetention   time.Duration
+	StatsRetention time.Duration
+}
+
+type RankingConfig struct {
+	Interval time.Duration
+	HalfLife time.Duration
+	Window   time.Duration
+}
+
+// SchedulingConfig sets how often scheduled posts that are due are
+// published.
+type SchedulingConfig struct {
+	Interval time.Duration
+}
+
+type TrashConfig struct {
+	Retention     time.Duration
+	PurgeInterval time.Duration
+}
+
+// I18nConfig lists the locales posts can be written in. DefaultLocale is used
+// when a post or a reader does not name one.
+type I18nConfig struct {
+	DefaultLocale string
+	Locales       []string
+}
+
+// Supported reports whether locale is one of the configured locales.
+func (c I18nConfig) Supported(locale string) bool {
+	if locale == c.DefaultLocale {
+		return true
+	}
+	for _, l := range c.Locales {
+		if l == locale {
+			return true
+		}
+	}
+	return false
+}
+
+// LinksConfig lists the hosts the site is served from. Absolute links to
+// these hosts are treated as internal, like relative ones.
+type LinksConfig struct {
+	Hosts []string
+}
+
+// DuplicatesConfig controls near-duplicate detection on posts and comments.
+// Threshold is the estimated share of shared phrases, between 0 and 1, above
+// which two texts count as duplicates. Texts shorter than MinWords are not
+// checked.
+type DuplicatesConfig struct {
+	Threshold        float64
+	MinWords         int
+	WarnPosts        bool
+	ModerateComments bool
+}
+
+// SEOConfig describes the public site for the metadata returned with posts.
+// SiteURL is the base of canonical URLs; without it they are left relative.
+type SEOConfig struct {
+	SiteURL     string
+	SiteName    string
+	TwitterSite string
+}
+
+type BulkConfig struct {
+	SyncLimit    int
+	MaxItems     int
+	BatchSize    int
+	PollInterval time.Duration
+}
+
 func Load() *Config {
 	return &Config{
 		Environment: getEnv("ENVIRONMENT", "development"),
@@ -66,14 +170,68 @@ func Load() *Config {
 			ConnMaxLifetime: getDurationEnv("DB_CONN_MAX_LIFETIME", 5*time.Minute),
 		},
 		JWT: JWTConfig{
-			Secret:          getEnv("JWT_SECRET", "your-secret-key-change-in-production"),
-			AccessTokenTTL:  getDurationEnv("JWT_ACCESS_TOKEN_TTL", 15*time.Minute),
-			RefreshTokenTTL: getDurationEnv("JWT_REFRESH_TOKEN_TTL", 7*24*time.Hour),
+			Secret:            getEnv("JWT_SECRET", "your-secret-key-change-in-production"),
+			AccessTokenTTL:    getDurationEnv("JWT_ACCESS_TOKEN_TTL", 15*time.Minute),
+			RefreshTokenTTL:   getDurationEnv("JWT_REFRESH_TOKEN_TTL", 7*24*time.Hour),
+			PreviewTTL:        getDurationEnv("PREVIEW_LINK_TTL", 7*24*time.Hour),
+			PreviewMaxTTL:     getDurationEnv("PREVIEW_LINK_MAX_TTL", 30*24*time.Hour),
+			UnlockTTL:         getDurationEnv("POST_UNLOCK_TTL", time.Hour),
+			UnlockMaxAttempts: getIntEnv("POST_UNLOCK_MAX_ATTEMPTS", 5),
+			UnlockLockout:     getDurationEnv("POST_UNLOCK_LOCKOUT", 15*time.Minute),
 		},
 		Cache: CacheConfig{
 			DefaultExpiration: getDurationEnv("CACHE_DEFAULT_EXPIRATION", 5*time.Minute),
 			CleanupInterval:   getDurationEnv("CACHE_CLEANUP_INTERVAL", 10*time.Minute),
 		},
+		Related: RelatedConfig{
+			RefreshInterval: getDurationEnv("RELATED_REFRESH_INTERVAL", 6*time.Hour),
+			MaxResults:      getIntEnv("RELATED_MAX_RESULTS", 10),
+		},
+		Views: ViewsConfig{
+			FlushInterval: getDurationEnv("VIEWS_FLUSH_INTERVAL", 30*time.Second),
+			DedupeWindow:  getDurationEnv("VIEWS_DEDUPE_WINDOW", 30*time.Minute),
+		},
+		Analytics: AnalyticsConfig{
+			RollupInterval: getDurationEnv("ANALYTICS_ROLLUP_INTERVAL", 15*time.Minute),
+			RawRetention:   getDurationEnv("ANALYTICS_RAW_RETENTION", 30*24*time.Hour),
+			StatsRetention: getDurationEnv("ANALYTICS_STATS_RETENTION", 2*365*24*time.Hour),
+		},
+		Ranking: RankingConfig{
+			Interval: getDurationEnv("RANKING_INTERVAL", 10*time.Minute),
+			HalfLife: getDurationEnv("RANKING_HALF_LIFE", 24*time.Hour),
+			Window:   getDurationEnv("RANKING_WINDOW", 7*24*time.Hour),
+		},
+		Bulk: BulkConfig{
+			SyncLimit:    getIntEnv("BULK_SYNC_LIMIT", 100),
+			MaxItems:     getIntEnv("BULK_MAX_ITEMS", 10000),
+			BatchSize:    getIntEnv("BULK_BATCH_SIZE", 100),
+			PollInterval: getDurationEnv("BULK_POLL_INTERVAL", 10*time.Second),
+		},
+		Trash: TrashConfig{
+			Retention:     getDurationEnv("TRASH_RETENTION", 30*24*time.Hour),
+			PurgeInterval: getDurationEnv("TRASH_PURGE_INTERVAL", time.Hour),
+		},
+		I18n: I18nConfig{
+			DefaultLocale: getEnv("DEFAULT_LOCALE", "en"),
+			Locales:       getListEnv("LOCALES", []string{"en", "he"}),
+		},
+		Links: LinksConfig{
+			Hosts: getListEnv("SITE_HOSTS", nil),
+		},
+		Duplicates: DuplicatesConfig{
+			Threshold:        getFloatEnv("DUPLICATE_THRESHOLD", 0.8),
+			MinWords:         getIntEnv("DUPLICATE_MIN_WORDS", 10),
+			WarnPosts:        getBoolEnv("DUPLICATE_WARN_POSTS", true),
+			ModerateComments: getBoolEnv("DUPLICATE_MODERATE_COMMENTS", true),
+		},
+		Scheduling: SchedulingConfig{
+			Interval: getDurationEnv("SCHEDULE_INTERVAL", time.Minute),
+		},
+		SEO: SEOConfig{
+			SiteURL:     strings.TrimRight(getEnv("SITE_URL", ""), "/"),
+			SiteName:    getEnv("SITE_NAME", "ModernBlog"),
+			TwitterSite: getEnv("TWITTER_SITE", ""),
+		},
 	}
 }
 
@@ -84,6 +242,22 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
+func getListEnv(key string, defaultValue []string) []string {
+	if value := os.Getenv(key); value != "" {
+		var list []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		if len(list) > 0 {
+			return list
+		}
+		log.Printf("Invalid list value for %s: %s, using default: %v", key, value, defaultValue)
+	}
+	return defaultValue
+}
+
 func getIntEnv(key string, defaultValue int) int {
 	if value := os.Getenv(key); value != "" {
 		if intValue, err := strconv.Atoi(value); err == nil {
@@ -94,6 +268,26 @@ func getIntEnv(key string, defaultValue int) int {
 	return defaultValue
 }
 
+func getFloatEnv(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatValue
+		}
+		log.Printf("Invalid float value for %s: %s, using default: %v", key, value, defaultValue)
+	}
+	return defaultValue
+}
+
+func getBoolEnv(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+		log.Printf("Invalid boolean value for %s: %s, using default: %t", key, value, defaultValue)
+	}
+	return defaultValue
+}
+
 func getDurationEnv(key string, defaultValue time.Duration) time.Duration {
 	if value := os.Getenv(key); value != "" {
 		if duration, err := time.ParseDuration(value); err == nil {
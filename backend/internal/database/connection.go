@@ -54,11 +54,52 @@ func Connect(cfg config.DatabaseConfig) (*gorm.DB, error) {
 }
 
 func Migrate(db *gorm.DB) error {
-	return db.AutoMigrate(
+	if err := db.AutoMigrate(
 		&models.User{},
 		&models.Post{},
+		&models.PostSlug{},
+		&models.PreviewLink{},
+		&models.PostCollaborator{},
+		&models.PostReview{},
+		&models.Annotation{},
+		&models.PostAutosave{},
+		&models.PostLink{},
+		&models.ContentFingerprint{},
+		&models.ContentFingerprintBand{},
+		&models.Series{},
+		&models.SeriesPost{},
+		&models.RelatedPost{},
+		&models.PostEvent{},
+		&models.PostDailyStat{},
+		&models.PostReferrerStat{},
+		&models.BookmarkFolder{},
+		&models.Bookmark{},
+		&models.ReadingProgress{},
+		&models.BulkJob{},
+		&models.LegacyURL{},
+		&models.ImportRecord{},
 		&models.Comment{},
 		&models.Tag{},
+		&models.Category{},
 		&models.Like{},
-	)
+	); err != nil {
+		return err
+	}
+
+	// Slugs used to be unique across all posts; they are now unique per
+	// locale, so the old single-column indexes have to go.
+	for _, index := range []struct {
+		model interface{}
+		name  string
+	}{
+		{&models.Post{}, "idx_posts_slug"},
+		{&models.PostSlug{}, "idx_post_slugs_slug"},
+	} {
+		if db.Migrator().HasIndex(index.model, index.name) {
+			if err := db.Migrator().DropIndex(index.model, index.name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
 }
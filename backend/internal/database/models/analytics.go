@@ -0,0 +1,63 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type PostEventType string
+
+const (
+	PostEventView    PostEventType = "view"
+	PostEventLike    PostEventType = "like"
+	PostEventComment PostEventType = "comment"
+)
+
+// PostEvent is a raw engagement event. Events are rolled up into daily
+// aggregates and deleted once they fall outside the raw retention period.
+type PostEvent struct {
+	ID         uuid.UUID     `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	PostID     uuid.UUID     `gorm:"type:uuid;not null;index:idx_post_events_post_day" json:"post_id"`
+	Day        time.Time     `gorm:"type:date;not null;index:idx_post_events_post_day" json:"day"`
+	Type       PostEventType `gorm:"not null" json:"type"`
+	ViewerHash string        `gorm:"size:64" json:"-"`
+	Referrer   string        `gorm:"size:255" json:"referrer"`
+	RolledUp   bool          `gorm:"default:false;index" json:"-"`
+	OccurredAt time.Time     `gorm:"not null;index" json:"occurred_at"`
+}
+
+func (e *PostEvent) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return nil
+}
+
+// NewPostEvent returns an event for postID stamped with the current UTC day.
+func NewPostEvent(postID uuid.UUID, eventType PostEventType) PostEvent {
+	now := time.Now().UTC()
+	return PostEvent{
+		PostID:     postID,
+		Day:        now.Truncate(24 * time.Hour),
+		Type:       eventType,
+		OccurredAt: now,
+	}
+}
+
+type PostDailyStat struct {
+	PostID        uuid.UUID `gorm:"type:uuid;primaryKey" json:"post_id"`
+	Day           time.Time `gorm:"type:date;primaryKey" json:"day"`
+	Views         int64     `gorm:"default:0" json:"views"`
+	UniqueViewers int64     `gorm:"default:0" json:"unique_viewers"`
+	Likes         int64     `gorm:"default:0" json:"likes"`
+	Comments      int64     `gorm:"default:0" json:"comments"`
+}
+
+type PostReferrerStat struct {
+	PostID uuid.UUID `gorm:"type:uuid;primaryKey" json:"post_id"`
+	Day    time.Time `gorm:"type:date;primaryKey" json:"day"`
+	Domain string    `gorm:"primaryKey;size:255" json:"domain"`
+	Views  int64     `gorm:"default:0" json:"views"`
+}
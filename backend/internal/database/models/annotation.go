@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Annotation is a private editorial note on a post. Top-level annotations are
+// anchored to a range of the post's content, counted in Unicode code points,
+// as of PostVersion; replies inherit the anchor of their parent. When the
+// content changes the anchor is moved to where the quoted text went, or the
+// annotation is marked orphaned if the text is gone.
+type Annotation struct {
+	ID          uuid.UUID      `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	PostID      uuid.UUID      `gorm:"type:uuid;not null;index" json:"post_id"`
+	ParentID    *uuid.UUID     `gorm:"type:uuid;index" json:"parent_id"`
+	AuthorID    uuid.UUID      `gorm:"type:uuid;not null" json:"author_id"`
+	Author      *User          `gorm:"foreignKey:AuthorID" json:"author,omitempty"`
+	Body        string         `gorm:"type:text;not null" json:"body"`
+	PostVersion int            `gorm:"not null" json:"post_version"`
+	Start       int            `gorm:"column:start_offset" json:"start"`
+	End         int            `gorm:"column:end_offset" json:"end"`
+	Quote       string         `gorm:"type:text" json:"quote"`
+	Prefix      string         `gorm:"type:text" json:"-"`
+	Suffix      string         `gorm:"type:text" json:"-"`
+	Orphaned    bool           `gorm:"default:false" json:"orphaned"`
+	Resolved    bool           `gorm:"default:false;index" json:"resolved"`
+	ResolvedBy  *uuid.UUID     `gorm:"type:uuid" json:"resolved_by"`
+	ResolvedAt  *time.Time     `json:"resolved_at"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+
+	Replies []Annotation `gorm:"foreignKey:ParentID" json:"replies,omitempty"`
+}
+
+func (a *Annotation) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	return nil
+}
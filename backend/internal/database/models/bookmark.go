@@ -0,0 +1,51 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type BookmarkFolder struct {
+	ID        uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_bookmark_folder_name" json:"user_id"`
+	Name      string    `gorm:"not null;uniqueIndex:idx_bookmark_folder_name" json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (f *BookmarkFolder) BeforeCreate(tx *gorm.DB) error {
+	if f.ID == uuid.Nil {
+		f.ID = uuid.New()
+	}
+	return nil
+}
+
+type Bookmark struct {
+	ID        uuid.UUID       `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	UserID    uuid.UUID       `gorm:"type:uuid;not null;uniqueIndex:idx_bookmark_user_post" json:"user_id"`
+	PostID    uuid.UUID       `gorm:"type:uuid;not null;uniqueIndex:idx_bookmark_user_post;index" json:"post_id"`
+	Post      *Post           `gorm:"foreignKey:PostID" json:"post,omitempty"`
+	FolderID  *uuid.UUID      `gorm:"type:uuid;index" json:"folder_id"`
+	Folder    *BookmarkFolder `gorm:"foreignKey:FolderID" json:"folder,omitempty"`
+	Note      string          `gorm:"type:text" json:"note"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+func (b *Bookmark) BeforeCreate(tx *gorm.DB) error {
+	if b.ID == uuid.Nil {
+		b.ID = uuid.New()
+	}
+	return nil
+}
+
+// ReadingProgress tracks how far a user has read a post. It is kept apart
+// from bookmarks so progress is remembered for any post a reader opens.
+type ReadingProgress struct {
+	UserID     uuid.UUID `gorm:"type:uuid;primaryKey" json:"user_id"`
+	PostID     uuid.UUID `gorm:"type:uuid;primaryKey" json:"post_id"`
+	Percent    float64   `gorm:"not null;default:0" json:"percent"`
+	LastReadAt time.Time `gorm:"not null" json:"last_read_at"`
+}
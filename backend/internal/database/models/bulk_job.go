@@ -0,0 +1,137 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type BulkAction string
+
+const (
+	BulkActionPublish        BulkAction = "publish"
+	BulkActionArchive        BulkAction = "archive"
+	BulkActionDelete         BulkAction = "delete"
+	BulkActionRestore        BulkAction = "restore"
+	BulkActionAddTags        BulkAction = "add_tags"
+	BulkActionRemoveTags     BulkAction = "remove_tags"
+	BulkActionReassignAuthor BulkAction = "reassign_author"
+)
+
+func (a BulkAction) Valid() bool {
+	switch a {
+	case BulkActionPublish, BulkActionArchive, BulkActionDelete, BulkActionRestore,
+		BulkActionAddTags, BulkActionRemoveTags, BulkActionReassignAuthor:
+		return true
+	}
+	return false
+}
+
+type BulkJobStatus string
+
+const (
+	BulkJobStatusPending   BulkJobStatus = "pending"
+	BulkJobStatusRunning   BulkJobStatus = "running"
+	BulkJobStatusCompleted BulkJobStatus = "completed"
+	BulkJobStatusFailed    BulkJobStatus = "failed"
+)
+
+// BulkParams holds the action-specific arguments of a bulk operation.
+type BulkParams struct {
+	Tags     []string   `json:"tags,omitempty"`
+	AuthorID *uuid.UUID `json:"author_id,omitempty"`
+}
+
+func (p BulkParams) Value() (driver.Value, error) {
+	return jsonValue(p)
+}
+
+func (p *BulkParams) Scan(value interface{}) error {
+	return jsonScan(value, p)
+}
+
+type UUIDList []uuid.UUID
+
+func (l UUIDList) Value() (driver.Value, error) {
+	if l == nil {
+		return "[]", nil
+	}
+	return jsonValue(l)
+}
+
+func (l *UUIDList) Scan(value interface{}) error {
+	return jsonScan(value, l)
+}
+
+// BulkFailure records why a bulk operation could not be applied to a post.
+type BulkFailure struct {
+	PostID uuid.UUID `json:"post_id"`
+	Error  string    `json:"error"`
+}
+
+type BulkFailures []BulkFailure
+
+func (f BulkFailures) Value() (driver.Value, error) {
+	if f == nil {
+		return "[]", nil
+	}
+	return jsonValue(f)
+}
+
+func (f *BulkFailures) Scan(value interface{}) error {
+	return jsonScan(value, f)
+}
+
+// BulkJob is a bulk post operation too large to run within a request. The
+// posts are processed in batches and Processed is advanced with each batch,
+// so an interrupted job resumes where it stopped.
+type BulkJob struct {
+	ID         uuid.UUID     `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	CreatedBy  uuid.UUID     `gorm:"type:uuid;not null;index" json:"created_by"`
+	Action     BulkAction    `gorm:"not null" json:"action"`
+	Params     BulkParams    `gorm:"type:jsonb" json:"params"`
+	PostIDs    UUIDList      `gorm:"type:jsonb" json:"-"`
+	Status     BulkJobStatus `gorm:"default:'pending';index" json:"status"`
+	Total      int           `json:"total"`
+	Processed  int           `json:"processed"`
+	Failures   BulkFailures  `gorm:"type:jsonb" json:"failures"`
+	Error      string        `json:"error,omitempty"`
+	StartedAt  *time.Time    `json:"started_at"`
+	FinishedAt *time.Time    `json:"finished_at"`
+	CreatedAt  time.Time     `json:"created_at"`
+	UpdatedAt  time.Time     `json:"updated_at"`
+}
+
+func (j *BulkJob) BeforeCreate(tx *gorm.DB) error {
+	if j.ID == uuid.Nil {
+		j.ID = uuid.New()
+	}
+	return nil
+}
+
+func jsonValue(v interface{}) (driver.Value, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+func jsonScan(value interface{}, dest interface{}) error {
+	var b []byte
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case []byte:
+		b = v
+	case string:
+		b = []byte(v)
+	default:
+		return errors.New("unsupported type for jsonb column")
+	}
+	return json.Unmarshal(b, dest)
+}
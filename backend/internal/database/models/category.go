@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Category is a node in the curated category tree. Path holds the IDs from
+// the root down to and including this node, e.g. "/<root>/<child>/", so a
+// subtree can be matched with a prefix query.
+type Category struct {
+	ID          uuid.UUID      `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	Name        string         `gorm:"not null" json:"name"`
+	Slug        string         `gorm:"uniqueIndex;not null" json:"slug"`
+	Description string         `gorm:"type:text" json:"description"`
+	ParentID    *uuid.UUID     `gorm:"type:uuid;index" json:"parent_id"`
+	Parent      *Category      `gorm:"foreignKey:ParentID" json:"parent,omitempty"`
+	Path        string         `gorm:"index;not null" json:"path"`
+	Position    int            `gorm:"default:0" json:"position"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+
+	Children []Category `gorm:"foreignKey:ParentID" json:"children,omitempty"`
+}
+
+func (c *Category) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+	return nil
+}
+
+// ChildPath returns the path a direct child of this category would have.
+func (c *Category) ChildPath(childID uuid.UUID) string {
+	return c.Path + childID.String() + "/"
+}
@@ -17,7 +17,9 @@ type Comment struct {
 	Parent     *Comment       `gorm:"foreignKey:ParentID" json:"parent,omitempty"`
 	Content    string         `gorm:"type:text;not null" json:"content"`
 	IsApproved bool           `gorm:"default:false" json:"is_approved"`
+	Held       bool           `gorm:"not null;default:false;index" json:"held"`
 	LikeCount  int            `gorm:"default:0" json:"like_count"`
+	Version    int            `gorm:"not null;default:1" json:"version"`
 	CreatedAt  time.Time      `json:"created_at"`
 	UpdatedAt  time.Time      `json:"updated_at"`
 	DeletedAt  gorm.DeletedAt `gorm:"index" json:"-"`
@@ -33,12 +35,39 @@ func (c *Comment) BeforeCreate(tx *gorm.DB) error {
 	return nil
 }
 
+func (c *Comment) BeforeUpdate(tx *gorm.DB) error {
+	tx.Statement.SetColumn("version", gorm.Expr("version + 1"))
+	return nil
+}
+
+// Approve releases a held comment and counts it on its post.
+func (c *Comment) Approve(tx *gorm.DB) error {
+	if err := tx.Model(c).Updates(map[string]interface{}{"held": false, "is_approved": true}).Error; err != nil {
+		return err
+	}
+	c.Held = false
+	return c.AfterCreate(tx)
+}
+
+// AfterCreate counts the comment on its post. Comments held for moderation
+// are counted when they are approved.
 func (c *Comment) AfterCreate(tx *gorm.DB) error {
-	return tx.Model(&Post{}).Where("id = ?", c.PostID).
-		UpdateColumn("comment_count", gorm.Expr("comment_count + ?", 1)).Error
+	if c.Held {
+		return nil
+	}
+	if err := tx.Model(&Post{}).Where("id = ?", c.PostID).
+		UpdateColumn("comment_count", gorm.Expr("comment_count + ?", 1)).Error; err != nil {
+		return err
+	}
+
+	event := NewPostEvent(c.PostID, PostEventComment)
+	return tx.Create(&event).Error
 }
 
 func (c *Comment) AfterDelete(tx *gorm.DB) error {
+	if c.Held {
+		return nil
+	}
 	return tx.Model(&Post{}).Where("id = ?", c.PostID).
 		UpdateColumn("comment_count", gorm.Expr("comment_count - ?", 1)).Error
 }
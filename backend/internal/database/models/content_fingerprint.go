@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ContentFingerprint is the MinHash signature of a post's or comment's
+// content. DuplicateOfID points at the most similar other item of the same
+// kind when the two are near-duplicates.
+type ContentFingerprint struct {
+	Kind          string     `gorm:"size:20;primaryKey" json:"kind"`
+	ItemID        uuid.UUID  `gorm:"type:uuid;primaryKey" json:"item_id"`
+	Signature     []byte     `gorm:"not null" json:"-"`
+	DuplicateOfID *uuid.UUID `gorm:"type:uuid;index" json:"duplicate_of_id"`
+	Similarity    float64    `gorm:"not null;default:0" json:"similarity"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// ContentFingerprintBand is one locality-sensitive hash of a signature.
+// Items sharing a band hash are the candidates compared against each other.
+type ContentFingerprintBand struct {
+	Kind   string    `gorm:"size:20;primaryKey" json:"kind"`
+	Band   int       `gorm:"primaryKey;autoIncrement:false" json:"band"`
+	Hash   int64     `gorm:"primaryKey;autoIncrement:false" json:"hash"`
+	ItemID uuid.UUID `gorm:"type:uuid;primaryKey;index" json:"item_id"`
+}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ImportRecord links an item from an external source to the row it was
+// imported as, so that re-running an import updates or skips it instead of
+// creating a duplicate.
+type ImportRecord struct {
+	ID         uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	Source     string    `gorm:"not null;uniqueIndex:idx_import_record" json:"source"`
+	Kind       string    `gorm:"not null;uniqueIndex:idx_import_record" json:"kind"`
+	ExternalID string    `gorm:"not null;uniqueIndex:idx_import_record" json:"external_id"`
+	LocalID    uuid.UUID `gorm:"type:uuid;not null;index" json:"local_id"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+func (r *ImportRecord) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
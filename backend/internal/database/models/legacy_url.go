@@ -0,0 +1,48 @@
+package models
+
+import (
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// LegacyURL maps a path on a previous site, such as a WordPress permalink,
+// to the post that replaced it.
+type LegacyURL struct {
+	ID        uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	Path      string    `gorm:"uniqueIndex;not null" json:"path"`
+	PostID    uuid.UUID `gorm:"type:uuid;not null;index" json:"post_id"`
+	Post      *Post     `gorm:"foreignKey:PostID" json:"post,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// LegacyPath normalizes a URL or path for storage and lookup: the host is
+// dropped and a trailing slash is trimmed, keeping any query string.
+func LegacyPath(raw string) string {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return ""
+	}
+
+	path := u.EscapedPath()
+	if path != "/" {
+		path = strings.TrimSuffix(path, "/")
+	}
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+	return path
+}
+
+func (l *LegacyURL) BeforeCreate(tx *gorm.DB) error {
+	if l.ID == uuid.Nil {
+		l.ID = uuid.New()
+	}
+	return nil
+}
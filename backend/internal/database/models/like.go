@@ -27,8 +27,13 @@ func (l *Like) BeforeCreate(tx *gorm.DB) error {
 
 func (l *Like) AfterCreate(tx *gorm.DB) error {
 	if l.PostID != nil {
-		return tx.Model(&Post{}).Where("id = ?", l.PostID).
-			UpdateColumn("like_count", gorm.Expr("like_count + ?", 1)).Error
+		if err := tx.Model(&Post{}).Where("id = ?", l.PostID).
+			UpdateColumn("like_count", gorm.Expr("like_count + ?", 1)).Error; err != nil {
+			return err
+		}
+
+		event := NewPostEvent(*l.PostID, PostEventLike)
+		return tx.Create(&event).Error
 	}
 	if l.CommentID != nil {
 		return tx.Model(&Comment{}).Where("id = ?", l.CommentID).
@@ -1,9 +1,15 @@
 package models
 
 import (
+	"crypto/sha256"
+	"database/sql/driver"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
@@ -11,31 +17,196 @@ type PostStatus string
 
 const (
 	PostStatusDraft     PostStatus = "draft"
+	PostStatusInReview  PostStatus = "in_review"
+	PostStatusScheduled PostStatus = "scheduled"
 	PostStatusPublished PostStatus = "published"
 	PostStatusArchived  PostStatus = "archived"
 )
 
+func (s PostStatus) Valid() bool {
+	switch s {
+	case PostStatusDraft, PostStatusInReview, PostStatusScheduled, PostStatusPublished, PostStatusArchived:
+		return true
+	}
+	return false
+}
+
+var postTransitions = map[PostStatus][]PostStatus{
+	PostStatusDraft:     {PostStatusInReview, PostStatusScheduled, PostStatusPublished, PostStatusArchived},
+	PostStatusInReview:  {PostStatusDraft, PostStatusScheduled, PostStatusPublished},
+	PostStatusScheduled: {PostStatusDraft, PostStatusPublished, PostStatusArchived},
+	PostStatusPublished: {PostStatusDraft, PostStatusArchived},
+	PostStatusArchived:  {PostStatusDraft, PostStatusPublished},
+}
+
+// CanTransitionTo reports whether a post may move from s to next. Staying in
+// the same status is always allowed.
+func (s PostStatus) CanTransitionTo(next PostStatus) bool {
+	if s == next {
+		return next.Valid()
+	}
+	for _, allowed := range postTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// PostVisibility controls who can read a post, independently of its status.
+type PostVisibility string
+
+const (
+	PostVisibilityPublic   PostVisibility = "public"
+	PostVisibilityUnlisted PostVisibility = "unlisted"
+	PostVisibilityMembers  PostVisibility = "members"
+	PostVisibilityPrivate  PostVisibility = "private"
+	PostVisibilityPassword PostVisibility = "password"
+)
+
+func (v PostVisibility) Valid() bool {
+	switch v {
+	case PostVisibilityPublic, PostVisibilityUnlisted, PostVisibilityMembers, PostVisibilityPrivate, PostVisibilityPassword:
+		return true
+	}
+	return false
+}
+
+// Listed reports whether posts with this visibility appear in lists and feeds.
+// Unlisted and private posts are only reachable directly.
+func (v PostVisibility) Listed() bool {
+	return v != PostVisibilityUnlisted && v != PostVisibilityPrivate
+}
+
+// TOCEntry is a single heading in a post's generated table of contents.
+type TOCEntry struct {
+	Level int    `json:"level"`
+	ID    string `json:"id"`
+	Text  string `json:"text"`
+}
+
+type TableOfContents []TOCEntry
+
+func (t TableOfContents) Value() (driver.Value, error) {
+	if t == nil {
+		return "[]", nil
+	}
+	b, err := json.Marshal(t)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+func (t *TableOfContents) Scan(value interface{}) error {
+	var b []byte
+	switch v := value.(type) {
+	case nil:
+		*t = TableOfContents{}
+		return nil
+	case []byte:
+		b = v
+	case string:
+		b = []byte(v)
+	default:
+		return errors.New("unsupported type for TableOfContents")
+	}
+	return json.Unmarshal(b, t)
+}
+
 type Post struct {
-	ID            uuid.UUID      `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
-	Title         string         `gorm:"not null" json:"title"`
-	Slug          string         `gorm:"uniqueIndex;not null" json:"slug"`
-	Content       string         `gorm:"type:text;not null" json:"content"`
-	Excerpt       string         `gorm:"type:text" json:"excerpt"`
-	FeaturedImage string         `json:"featured_image"`
-	Status        PostStatus     `gorm:"default:'draft'" json:"status"`
-	AuthorID      uuid.UUID      `gorm:"type:uuid;not null" json:"author_id"`
-	Author        *User          `gorm:"foreignKey:AuthorID" json:"author,omitempty"`
-	PublishedAt   *time.Time     `json:"published_at"`
-	ViewCount     int            `gorm:"default:0" json:"view_count"`
-	LikeCount     int            `gorm:"default:0" json:"like_count"`
-	CommentCount  int            `gorm:"default:0" json:"comment_count"`
-	CreatedAt     time.Time      `json:"created_at"`
-	UpdatedAt     time.Time      `json:"updated_at"`
-	DeletedAt     gorm.DeletedAt `gorm:"index" json:"-"`
-
-	Comments []Comment `gorm:"foreignKey:PostID" json:"comments,omitempty"`
-	Tags     []Tag     `gorm:"many2many:post_tags;" json:"tags,omitempty"`
-	Likes    []Like    `gorm:"foreignKey:PostID" json:"likes,omitempty"`
+	ID            uuid.UUID       `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	Title         string          `gorm:"not null" json:"title"`
+	Slug          string          `gorm:"uniqueIndex:idx_posts_locale_slug,priority:2;not null" json:"slug"`
+	Locale        string          `gorm:"size:10;not null;default:'en';uniqueIndex:idx_posts_locale_slug,priority:1" json:"locale"`
+	SlugPinned    bool            `gorm:"default:false" json:"slug_pinned"`
+	Content       string          `gorm:"type:text;not null" json:"content"`
+	ContentHTML   string          `gorm:"type:text" json:"content_html"`
+	ContentHash   string          `gorm:"size:64" json:"-"`
+	TOC           TableOfContents `gorm:"type:jsonb" json:"toc"`
+	WordCount     int             `gorm:"default:0" json:"word_count"`
+	ReadingTime   int             `gorm:"default:0" json:"reading_time"`
+	Excerpt       string          `gorm:"type:text" json:"excerpt"`
+	FeaturedImage string          `json:"featured_image"`
+	Status        PostStatus      `gorm:"default:'draft'" json:"status"`
+	Visibility    PostVisibility  `gorm:"size:20;not null;default:'public';index" json:"visibility"`
+	PasswordHash  string          `json:"-"`
+
+	// ExcerptGenerated is set when Excerpt was derived from the content
+	// rather than written, so it is regenerated when the content changes.
+	ExcerptGenerated bool `gorm:"not null;default:false" json:"excerpt_generated"`
+
+	SEO PostSEO `gorm:"embedded;embeddedPrefix:seo_" json:"seo"`
+
+	// TranslationGroupID links posts that are translations of each other,
+	// at most one per locale.
+	TranslationGroupID *uuid.UUID `gorm:"type:uuid;index" json:"translation_group_id"`
+
+	AuthorID     uuid.UUID  `gorm:"type:uuid;not null" json:"author_id"`
+	Author       *User      `gorm:"foreignKey:AuthorID" json:"author,omitempty"`
+	CategoryID   *uuid.UUID `gorm:"type:uuid;index" json:"category_id"`
+	Category     *Category  `gorm:"foreignKey:CategoryID" json:"category,omitempty"`
+	PublishedAt  *time.Time `json:"published_at"`
+	ScheduledAt  *time.Time `gorm:"index" json:"scheduled_at"`
+	ViewCount    int        `gorm:"default:0" json:"view_count"`
+	LikeCount    int        `gorm:"default:0" json:"like_count"`
+	CommentCount int        `gorm:"default:0" json:"comment_count"`
+
+	// Version is bumped on every update through the model (but not on
+	// counter updates) and is exposed as the post's ETag.
+	Version int `gorm:"not null;default:1" json:"version"`
+
+	// Ranking scores are recomputed periodically rather than on every write,
+	// which keeps orderings stable while a client pages through them.
+	TrendingScore   float64 `gorm:"default:0;index" json:"trending_score"`
+	PopularityScore float64 `gorm:"default:0;index" json:"popularity_score"`
+
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+
+	Comments []Comment  `gorm:"foreignKey:PostID" json:"comments,omitempty"`
+	Tags     []Tag      `gorm:"many2many:post_tags;" json:"tags,omitempty"`
+	Likes    []Like     `gorm:"foreignKey:PostID" json:"likes,omitempty"`
+	Slugs    []PostSlug `gorm:"foreignKey:PostID" json:"-"`
+
+	// CoAuthors holds the accepted owner and co-author collaborators shown in
+	// the byline besides Author; load it with a filtered preload.
+	CoAuthors []PostCollaborator `gorm:"foreignKey:PostID" json:"co_authors,omitempty"`
+
+	// SecondaryCategories are optional in addition to the primary Category.
+	SecondaryCategories []Category `gorm:"many2many:post_categories;" json:"secondary_categories,omitempty"`
+
+	// Viewer-specific state, filled in only for authenticated callers.
+	Bookmarked   *bool    `gorm:"-" json:"bookmarked,omitempty"`
+	ReadProgress *float64 `gorm:"-" json:"read_progress,omitempty"`
+
+	// Locked is set when the content was withheld from the viewer and only
+	// the excerpt is shown.
+	Locked bool `gorm:"-" json:"locked,omitempty"`
+}
+
+func (p *Post) SetPassword(password string) error {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	p.PasswordHash = string(hash)
+	return nil
+}
+
+func (p *Post) CheckPassword(password string) bool {
+	if p.PasswordHash == "" {
+		return false
+	}
+	return bcrypt.CompareHashAndPassword([]byte(p.PasswordHash), []byte(password)) == nil
+}
+
+// PasswordStamp identifies the current password without revealing it. Unlock
+// tokens carry it so that changing the password invalidates them.
+func (p *Post) PasswordStamp() string {
+	sum := sha256.Sum256([]byte(p.PasswordHash))
+	return hex.EncodeToString(sum[:8])
 }
 
 func (p *Post) BeforeCreate(tx *gorm.DB) error {
@@ -50,5 +221,6 @@ func (p *Post) BeforeUpdate(tx *gorm.DB) error {
 		now := time.Now()
 		p.PublishedAt = &now
 	}
+	tx.Statement.SetColumn("version", gorm.Expr("version + 1"))
 	return nil
 }
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PostAutosave is a user's unsaved working copy of a post. It is written by
+// the editor's autosave and leaves the post itself untouched until the user
+// saves.
+type PostAutosave struct {
+	PostID  uuid.UUID `gorm:"type:uuid;primaryKey" json:"post_id"`
+	UserID  uuid.UUID `gorm:"type:uuid;primaryKey;index" json:"user_id"`
+	Title   string    `json:"title"`
+	Content string    `gorm:"type:text" json:"content"`
+	Excerpt string    `gorm:"type:text" json:"excerpt"`
+	// BaseVersion is the post version the working copy was started from.
+	BaseVersion int       `gorm:"not null" json:"base_version"`
+	SavedAt     time.Time `gorm:"not null" json:"saved_at"`
+}
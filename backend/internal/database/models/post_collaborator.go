@@ -0,0 +1,86 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type CollaboratorRole string
+
+const (
+	// CollaboratorRoleOwner can edit, delete and manage collaborators.
+	CollaboratorRoleOwner CollaboratorRole = "owner"
+	// CollaboratorRoleCoAuthor can edit and is shown in the byline.
+	CollaboratorRoleCoAuthor CollaboratorRole = "co_author"
+	// CollaboratorRoleEditor can edit but is not shown in the byline.
+	CollaboratorRoleEditor CollaboratorRole = "editor"
+)
+
+func (r CollaboratorRole) Valid() bool {
+	switch r {
+	case CollaboratorRoleOwner, CollaboratorRoleCoAuthor, CollaboratorRoleEditor:
+		return true
+	}
+	return false
+}
+
+type CollaboratorStatus string
+
+const (
+	CollaboratorStatusPending  CollaboratorStatus = "pending"
+	CollaboratorStatusAccepted CollaboratorStatus = "accepted"
+	CollaboratorStatusDeclined CollaboratorStatus = "declined"
+)
+
+type PostCollaborator struct {
+	ID         uuid.UUID          `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	PostID     uuid.UUID          `gorm:"type:uuid;not null;uniqueIndex:idx_post_collaborator" json:"post_id"`
+	Post       *Post              `gorm:"foreignKey:PostID" json:"post,omitempty"`
+	UserID     uuid.UUID          `gorm:"type:uuid;not null;uniqueIndex:idx_post_collaborator;index" json:"user_id"`
+	User       *User              `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	Role       CollaboratorRole   `gorm:"not null" json:"role"`
+	Status     CollaboratorStatus `gorm:"default:'pending'" json:"status"`
+	InvitedBy  *uuid.UUID         `gorm:"type:uuid" json:"invited_by"`
+	AcceptedAt *time.Time         `json:"accepted_at"`
+	CreatedAt  time.Time          `json:"created_at"`
+	UpdatedAt  time.Time          `json:"updated_at"`
+}
+
+func (pc *PostCollaborator) BeforeCreate(tx *gorm.DB) error {
+	if pc.ID == uuid.Nil {
+		pc.ID = uuid.New()
+	}
+	return nil
+}
+
+// MoveOwnership hands the owner collaborator row of post's current author to
+// authorID, replacing any collaborator row authorID already has. Callers
+// change post.author_id themselves.
+func MoveOwnership(tx *gorm.DB, post *Post, authorID uuid.UUID) error {
+	if post.AuthorID == authorID {
+		return nil
+	}
+
+	if err := tx.Where("post_id = ? AND user_id = ?", post.ID, authorID).
+		Delete(&PostCollaborator{}).Error; err != nil {
+		return err
+	}
+
+	result := tx.Model(&PostCollaborator{}).
+		Where("post_id = ? AND user_id = ? AND role = ?", post.ID, post.AuthorID, CollaboratorRoleOwner).
+		Update("user_id", authorID)
+	if result.Error != nil || result.RowsAffected > 0 {
+		return result.Error
+	}
+
+	now := time.Now()
+	return tx.Create(&PostCollaborator{
+		PostID:     post.ID,
+		UserID:     authorID,
+		Role:       CollaboratorRoleOwner,
+		Status:     CollaboratorStatusAccepted,
+		AcceptedAt: &now,
+	}).Error
+}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PostLink is an edge in the internal link graph: a link in the source
+// post's content that points at another post. TargetPostID is nil while the
+// link does not resolve to any post.
+type PostLink struct {
+	ID           uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	SourcePostID uuid.UUID  `gorm:"type:uuid;not null;index" json:"source_post_id"`
+	Source       *Post      `gorm:"foreignKey:SourcePostID" json:"source,omitempty"`
+	TargetPostID *uuid.UUID `gorm:"type:uuid;index" json:"target_post_id"`
+	Target       *Post      `gorm:"foreignKey:TargetPostID" json:"target,omitempty"`
+	Href         string     `gorm:"type:text;not null" json:"href"`
+	// TargetSlug and TargetLocale are set when the link names the post by
+	// slug rather than by ID or a legacy path.
+	TargetSlug   string    `gorm:"size:255;index:idx_post_links_target_slug" json:"target_slug,omitempty"`
+	TargetLocale string    `gorm:"size:10;index:idx_post_links_target_slug" json:"target_locale,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func (l *PostLink) BeforeCreate(tx *gorm.DB) error {
+	if l.ID == uuid.Nil {
+		l.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type ReviewStatus string
+
+const (
+	ReviewStatusPending          ReviewStatus = "pending"
+	ReviewStatusApproved         ReviewStatus = "approved"
+	ReviewStatusChangesRequested ReviewStatus = "changes_requested"
+	ReviewStatusWithdrawn        ReviewStatus = "withdrawn"
+)
+
+var (
+	ErrInvalidStatus    = errors.New("invalid status")
+	ErrStatusTransition = errors.New("status transition not allowed")
+	ErrSubmitForReview  = errors.New("submit the post for review instead of setting in_review")
+	ErrLeaveReview      = errors.New("withdraw the review or request changes to move the post back to draft")
+	ErrReviewRequired   = errors.New("contributors need an approved review of the current version to publish")
+)
+
+// PostReview is one round of editorial review, from submission to a
+// decision. An approval holds for the post version it was given on, so later
+// edits need another review.
+type PostReview struct {
+	ID          uuid.UUID    `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	PostID      uuid.UUID    `gorm:"type:uuid;not null;index" json:"post_id"`
+	Post        *Post        `gorm:"foreignKey:PostID" json:"post,omitempty"`
+	SubmittedBy uuid.UUID    `gorm:"type:uuid;not null" json:"submitted_by"`
+	Submitter   *User        `gorm:"foreignKey:SubmittedBy" json:"submitter,omitempty"`
+	ReviewerID  *uuid.UUID   `gorm:"type:uuid;index" json:"reviewer_id"`
+	Reviewer    *User        `gorm:"foreignKey:ReviewerID" json:"reviewer,omitempty"`
+	Status      ReviewStatus `gorm:"not null;default:'pending';index" json:"status"`
+	Note        string       `gorm:"type:text" json:"note"`
+	DecidedBy   *uuid.UUID   `gorm:"type:uuid" json:"decided_by"`
+	Decider     *User        `gorm:"foreignKey:DecidedBy" json:"decider,omitempty"`
+	Comment     string       `gorm:"type:text" json:"comment"`
+	PostVersion int          `json:"post_version"`
+	DecidedAt   *time.Time   `json:"decided_at"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+}
+
+func (r *PostReview) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
+
+// CheckStatusChange reports why a user with role may not move post to next,
+// or nil if they may. Entering and leaving review goes through the review
+// endpoints so that every round is recorded.
+func CheckStatusChange(db *gorm.DB, role UserRole, post *Post, next PostStatus) error {
+	if !next.Valid() {
+		return ErrInvalidStatus
+	}
+	if next == post.Status {
+		return nil
+	}
+	if next == PostStatusInReview {
+		return ErrSubmitForReview
+	}
+	if post.Status == PostStatusInReview && next == PostStatusDraft {
+		return ErrLeaveReview
+	}
+	if !post.Status.CanTransitionTo(next) {
+		return ErrStatusTransition
+	}
+
+	if role != UserRoleContributor ||
+		(next != PostStatusPublished && next != PostStatusScheduled) {
+		return nil
+	}
+	if post.Status != PostStatusInReview {
+		return ErrReviewRequired
+	}
+
+	var approved int64
+	db.Model(&PostReview{}).
+		Where("post_id = ? AND status = ? AND post_version = ?", post.ID, ReviewStatusApproved, post.Version).
+		Count(&approved)
+	if approved == 0 {
+		return ErrReviewRequired
+	}
+	return nil
+}
+
+// ClosePendingReview settles the open review of a post that is published
+// straight out of review. An editor doing so counts as approving the version
+// being published; anyone else publishing withdraws the review.
+func ClosePendingReview(tx *gorm.DB, post *Post, userID uuid.UUID, editor bool) error {
+	pending := tx.Model(&PostReview{}).
+		Where("post_id = ? AND status = ?", post.ID, ReviewStatusPending)
+	if !editor {
+		return pending.Update("status", ReviewStatusWithdrawn).Error
+	}
+
+	now := time.Now().UTC()
+	return pending.Updates(map[string]interface{}{
+		"status":       ReviewStatusApproved,
+		"decided_by":   userID,
+		"decided_at":   now,
+		"post_version": post.Version,
+	}).Error
+}
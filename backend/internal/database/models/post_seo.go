@@ -0,0 +1,26 @@
+package models
+
+// TwitterCard is the kind of card shown when a post is shared on Twitter.
+type TwitterCard string
+
+const (
+	TwitterCardSummary           TwitterCard = "summary"
+	TwitterCardSummaryLargeImage TwitterCard = "summary_large_image"
+)
+
+func (c TwitterCard) Valid() bool {
+	return c == TwitterCardSummary || c == TwitterCardSummaryLargeImage
+}
+
+// PostSEO overrides the metadata search engines and link previews see for a
+// post. Empty fields fall back to values derived from the post itself.
+type PostSEO struct {
+	MetaTitle       string      `gorm:"size:255" json:"meta_title"`
+	MetaDescription string      `gorm:"size:500" json:"meta_description"`
+	CanonicalURL    string      `gorm:"size:2048" json:"canonical_url"`
+	OGTitle         string      `gorm:"size:255" json:"og_title"`
+	OGDescription   string      `gorm:"size:500" json:"og_description"`
+	OGImage         string      `gorm:"size:2048" json:"og_image"`
+	TwitterCard     TwitterCard `gorm:"size:30" json:"twitter_card"`
+	NoIndex         bool        `gorm:"not null;default:false" json:"noindex"`
+}
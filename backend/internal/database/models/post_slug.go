@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PostSlug records a slug a post was previously published under so that old
+// links can be redirected to the current slug.
+type PostSlug struct {
+	ID        uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	PostID    uuid.UUID `gorm:"type:uuid;not null;index" json:"post_id"`
+	Post      *Post     `gorm:"foreignKey:PostID" json:"post,omitempty"`
+	Locale    string    `gorm:"size:10;not null;default:'en';uniqueIndex:idx_post_slugs_locale_slug,priority:1" json:"locale"`
+	Slug      string    `gorm:"uniqueIndex:idx_post_slugs_locale_slug,priority:2;not null" json:"slug"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (s *PostSlug) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PreviewLink is a revocable grant to view a post read-only through a signed
+// token, regardless of its status.
+type PreviewLink struct {
+	ID        uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	PostID    uuid.UUID  `gorm:"type:uuid;not null;index" json:"post_id"`
+	Post      *Post      `gorm:"foreignKey:PostID" json:"post,omitempty"`
+	CreatedBy uuid.UUID  `gorm:"type:uuid;not null" json:"created_by"`
+	Note      string     `json:"note"`
+	ExpiresAt time.Time  `gorm:"not null" json:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+func (l *PreviewLink) BeforeCreate(tx *gorm.DB) error {
+	if l.ID == uuid.Nil {
+		l.ID = uuid.New()
+	}
+	return nil
+}
+
+func (l *PreviewLink) IsActive() bool {
+	return l.RevokedAt == nil && time.Now().Before(l.ExpiresAt)
+}
@@ -0,0 +1,16 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RelatedPost is a precomputed recommendation from one post to another.
+type RelatedPost struct {
+	PostID        uuid.UUID `gorm:"type:uuid;primaryKey" json:"post_id"`
+	RelatedPostID uuid.UUID `gorm:"type:uuid;primaryKey" json:"related_post_id"`
+	RelatedPost   *Post     `gorm:"foreignKey:RelatedPostID" json:"related_post,omitempty"`
+	Score         float64   `gorm:"not null;index" json:"score"`
+	ComputedAt    time.Time `json:"computed_at"`
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type Series struct {
+	ID          uuid.UUID      `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	Title       string         `gorm:"not null" json:"title"`
+	Slug        string         `gorm:"uniqueIndex;not null" json:"slug"`
+	Description string         `gorm:"type:text" json:"description"`
+	AuthorID    uuid.UUID      `gorm:"type:uuid;not null;index" json:"author_id"`
+	Author      *User          `gorm:"foreignKey:AuthorID" json:"author,omitempty"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+
+	Entries []SeriesPost `gorm:"foreignKey:SeriesID" json:"entries,omitempty"`
+}
+
+func (s *Series) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return nil
+}
+
+// SeriesPost places a post at a position within a series. A post belongs to
+// at most one series.
+type SeriesPost struct {
+	SeriesID  uuid.UUID `gorm:"type:uuid;primaryKey" json:"series_id"`
+	PostID    uuid.UUID `gorm:"type:uuid;primaryKey;uniqueIndex" json:"post_id"`
+	Post      *Post     `gorm:"foreignKey:PostID" json:"post,omitempty"`
+	Position  int       `gorm:"not null" json:"position"`
+	CreatedAt time.Time `json:"created_at"`
+}
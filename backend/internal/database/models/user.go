@@ -8,6 +8,25 @@ import (
 	"gorm.io/gorm"
 )
 
+type UserRole string
+
+const (
+	// UserRoleContributor writes posts but needs an editor's approval to
+	// publish them.
+	UserRoleContributor UserRole = "contributor"
+	UserRoleAuthor      UserRole = "author"
+	UserRoleEditor      UserRole = "editor"
+	UserRoleAdmin       UserRole = "admin"
+)
+
+func (r UserRole) Valid() bool {
+	switch r {
+	case UserRoleContributor, UserRoleAuthor, UserRoleEditor, UserRoleAdmin:
+		return true
+	}
+	return false
+}
+
 type User struct {
 	ID           uuid.UUID      `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
 	Username     string         `gorm:"uniqueIndex;not null" json:"username"`
@@ -17,6 +36,7 @@ type User struct {
 	LastName     string         `json:"last_name"`
 	AvatarURL    string         `json:"avatar_url"`
 	Bio          string         `gorm:"type:text" json:"bio"`
+	Role         UserRole       `gorm:"default:'author'" json:"role"`
 	IsActive     bool           `gorm:"default:true" json:"is_active"`
 	CreatedAt    time.Time      `json:"created_at"`
 	UpdatedAt    time.Time      `json:"updated_at"`
@@ -40,6 +60,12 @@ func (u *User) CheckPassword(password string) bool {
 	return err == nil
 }
 
+// IsEditor reports whether the user can perform editorial actions on content
+// they do not own.
+func (u *User) IsEditor() bool {
+	return u.Role == UserRoleEditor || u.Role == UserRoleAdmin
+}
+
 func (u *User) BeforeCreate(tx *gorm.DB) error {
 	if u.ID == uuid.Nil {
 		u.ID = uuid.New()
@@ -0,0 +1,247 @@
+// Package duplicates finds near-duplicate posts and comments. Content is
+// split into overlapping word shingles and summarised as a MinHash
+// signature, whose bands are indexed so that only likely matches are
+// compared.
+package duplicates
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"strings"
+	"unicode"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/yairfalse/modern-cloud-app/backend/internal/config"
+	"github.com/yairfalse/modern-cloud-app/backend/internal/database/models"
+)
+
+const (
+	// shingleSize is the number of words in each shingle.
+	shingleSize = 5
+	// bands and rows split a signature for lookup. Two texts with a
+	// Jaccard similarity of 0.8 share a band with a probability above 99.9%.
+	bands = 16
+	rows  = 4
+
+	numHashes = bands * rows
+)
+
+type Kind string
+
+const (
+	KindPost    Kind = "post"
+	KindComment Kind = "comment"
+)
+
+func (k Kind) Valid() bool {
+	return k == KindPost || k == KindComment
+}
+
+// Match is the most similar other item found for a piece of content.
+type Match struct {
+	ItemID     uuid.UUID
+	Similarity float64
+}
+
+type Detector struct {
+	cfg   config.DuplicatesConfig
+	seeds [numHashes]uint64
+}
+
+func NewDetector(cfg config.DuplicatesConfig) *Detector {
+	d := &Detector{cfg: cfg}
+	seed := uint64(0x9e3779b97f4a7c15)
+	for i := range d.seeds {
+		seed = mix(seed + uint64(i))
+		d.seeds[i] = seed
+	}
+	return d
+}
+
+// Config returns the settings the detector was created with.
+func (d *Detector) Config() config.DuplicatesConfig {
+	return d.cfg
+}
+
+// Check fingerprints content as the item itemID of kind and records its
+// closest match above the threshold, if any. It replaces the item's previous
+// fingerprint, and removes it when content is too short to compare. The
+// item does not need to exist yet, so Check can run before it is created.
+func (d *Detector) Check(tx *gorm.DB, kind Kind, itemID uuid.UUID, content string) (*Match, error) {
+	if err := forget(tx, kind, []uuid.UUID{itemID}); err != nil {
+		return nil, err
+	}
+
+	signature := d.signature(content)
+	if signature == nil {
+		return nil, nil
+	}
+
+	match, err := d.closest(tx, kind, itemID, signature)
+	if err != nil {
+		return nil, err
+	}
+
+	fingerprint := models.ContentFingerprint{
+		Kind:      string(kind),
+		ItemID:    itemID,
+		Signature: encode(signature),
+	}
+	if match != nil {
+		fingerprint.DuplicateOfID = &match.ItemID
+		fingerprint.Similarity = match.Similarity
+	}
+	if err := tx.Create(&fingerprint).Error; err != nil {
+		return nil, err
+	}
+
+	keys := make([]models.ContentFingerprintBand, 0, bands)
+	for band, hash := range bandHashes(signature) {
+		keys = append(keys, models.ContentFingerprintBand{Kind: string(kind), Band: band, Hash: hash, ItemID: itemID})
+	}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&keys).Error; err != nil {
+		return nil, err
+	}
+	return match, nil
+}
+
+// Remove deletes the fingerprints of items that are gone for good. Items
+// that were matched against them lose the match.
+func Remove(tx *gorm.DB, kind Kind, itemIDs ...uuid.UUID) error {
+	if len(itemIDs) == 0 {
+		return nil
+	}
+	if err := forget(tx, kind, itemIDs); err != nil {
+		return err
+	}
+	return tx.Model(&models.ContentFingerprint{}).
+		Where("kind = ? AND duplicate_of_id IN ?", kind, itemIDs).
+		UpdateColumns(map[string]interface{}{"duplicate_of_id": nil, "similarity": 0}).Error
+}
+
+// forget deletes the fingerprints of items but leaves matches against them.
+func forget(tx *gorm.DB, kind Kind, itemIDs []uuid.UUID) error {
+	if err := tx.Where("kind = ? AND item_id IN ?", kind, itemIDs).Delete(&models.ContentFingerprintBand{}).Error; err != nil {
+		return err
+	}
+	return tx.Where("kind = ? AND item_id IN ?", kind, itemIDs).Delete(&models.ContentFingerprint{}).Error
+}
+
+// closest compares signature with every item sharing one of its bands and
+// returns the most similar one at or above the threshold.
+func (d *Detector) closest(tx *gorm.DB, kind Kind, itemID uuid.UUID, signature []uint64) (*Match, error) {
+	keys := make([][]interface{}, 0, bands)
+	for band, hash := range bandHashes(signature) {
+		keys = append(keys, []interface{}{band, hash})
+	}
+
+	candidates := tx.Model(&models.ContentFingerprintBand{}).
+		Select("item_id").
+		Where("kind = ? AND item_id <> ? AND (band, hash) IN ?", kind, itemID, keys)
+
+	var fingerprints []models.ContentFingerprint
+	if err := tx.Select("item_id", "signature").
+		Where("kind = ? AND item_id IN (?)", kind, candidates).
+		Find(&fingerprints).Error; err != nil {
+		return nil, err
+	}
+
+	var best *Match
+	for _, fp := range fingerprints {
+		similarity := similarity(signature, decode(fp.Signature))
+		if similarity < d.cfg.Threshold {
+			continue
+		}
+		if best == nil || similarity > best.Similarity {
+			best = &Match{ItemID: fp.ItemID, Similarity: similarity}
+		}
+	}
+	return best, nil
+}
+
+// signature returns the MinHash signature of content, or nil when it has
+// fewer words than the configured minimum.
+func (d *Detector) signature(content string) []uint64 {
+	words := strings.FieldsFunc(strings.ToLower(content), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	if len(words) == 0 || len(words) < d.cfg.MinWords {
+		return nil
+	}
+
+	size := min(shingleSize, len(words))
+	signature := make([]uint64, numHashes)
+	for i := range signature {
+		signature[i] = ^uint64(0)
+	}
+	for i := 0; i+size <= len(words); i++ {
+		h := fnv.New64a()
+		h.Write([]byte(strings.Join(words[i:i+size], " ")))
+		shingle := h.Sum64()
+		for j, seed := range d.seeds {
+			if v := mix(shingle ^ seed); v < signature[j] {
+				signature[j] = v
+			}
+		}
+	}
+	return signature
+}
+
+// similarity estimates the Jaccard similarity of the shingle sets behind two
+// signatures.
+func similarity(a, b []uint64) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	same := 0
+	for i := range a {
+		if a[i] == b[i] {
+			same++
+		}
+	}
+	return float64(same) / float64(len(a))
+}
+
+func bandHashes(signature []uint64) []int64 {
+	hashes := make([]int64, bands)
+	buf := make([]byte, 8)
+	for band := range hashes {
+		h := fnv.New64a()
+		for _, v := range signature[band*rows : (band+1)*rows] {
+			binary.LittleEndian.PutUint64(buf, v)
+			h.Write(buf)
+		}
+		hashes[band] = int64(h.Sum64())
+	}
+	return hashes
+}
+
+// mix is the SplitMix64 finalizer. Applied to a shingle hash XORed with a
+// seed it gives one of the independent hash functions MinHash needs.
+func mix(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
+
+func encode(signature []uint64) []byte {
+	buf := make([]byte, 8*len(signature))
+	for i, v := range signature {
+		binary.LittleEndian.PutUint64(buf[i*8:], v)
+	}
+	return buf
+}
+
+func decode(buf []byte) []uint64 {
+	signature := make([]uint64, len(buf)/8)
+	for i := range signature {
+		signature[i] = binary.LittleEndian.Uint64(buf[i*8:])
+	}
+	return signature
+}
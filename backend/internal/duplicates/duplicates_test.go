@@ -0,0 +1,157 @@
+package duplicates
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/yairfalse/modern-cloud-app/backend/internal/config"
+)
+
+const threshold = 0.8
+
+func newTestDetector() *Detector {
+	return NewDetector(config.DuplicatesConfig{Threshold: threshold, MinWords: 10})
+}
+
+// words returns n distinct words starting at from, joined by spaces.
+func words(prefix string, from, n int) string {
+	w := make([]string, n)
+	for i := range w {
+		w[i] = fmt.Sprintf("%s%d", prefix, from+i)
+	}
+	return strings.Join(w, " ")
+}
+
+func TestSignatureMinWords(t *testing.T) {
+	d := newTestDetector()
+
+	if sig := d.signature(""); sig != nil {
+		t.Error("empty content has a signature")
+	}
+	if sig := d.signature(words("w", 0, 9)); sig != nil {
+		t.Error("content below MinWords has a signature")
+	}
+	if sig := d.signature(words("w", 0, 10)); len(sig) != numHashes {
+		t.Errorf("signature has %d hashes, want %d", len(sig), numHashes)
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	base := words("w", 0, 200)
+
+	tests := []struct {
+		name      string
+		a, b      string
+		duplicate bool
+	}{
+		{
+			name:      "identical",
+			a:         base,
+			b:         base,
+			duplicate: true,
+		},
+		{
+			name:      "case and punctuation",
+			a:         "The quick brown fox jumps over the lazy dog, then naps in the sun.",
+			b:         "the QUICK brown fox -- jumps over the lazy dog; then naps in the sun!",
+			duplicate: true,
+		},
+		{
+			name:      "one word changed",
+			a:         base,
+			b:         strings.Replace(base, "w100 ", "changed ", 1),
+			duplicate: true,
+		},
+		{
+			name:      "sentence appended",
+			a:         base,
+			b:         base + " and one more closing sentence here",
+			duplicate: true,
+		},
+		{
+			name: "half rewritten",
+			a:    base,
+			b:    words("w", 0, 100) + " " + words("x", 0, 100),
+		},
+		{
+			name: "unrelated",
+			a:    base,
+			b:    words("x", 0, 200),
+		},
+		{
+			name: "same words reversed",
+			a:    words("w", 0, 50),
+			b:    reversed(words("w", 0, 50)),
+		},
+		{
+			name:      "hebrew near-duplicate",
+			a:         strings.Repeat("שלום עולם זה טקסט בעברית ", 5) + words("מילה", 0, 60),
+			b:         strings.Repeat("שלום עולם זה טקסט בעברית ", 5) + words("מילה", 0, 60) + " סוף",
+			duplicate: true,
+		},
+	}
+
+	d := newTestDetector()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := d.signature(tt.a), d.signature(tt.b)
+			if a == nil || b == nil {
+				t.Fatal("missing signature")
+			}
+
+			got := similarity(a, b)
+			if got != similarity(b, a) {
+				t.Errorf("similarity is not symmetric")
+			}
+			if duplicate := got >= threshold; duplicate != tt.duplicate {
+				t.Errorf("similarity = %.2f, duplicate = %v, want %v", got, duplicate, tt.duplicate)
+			}
+
+			// A duplicate has to share a band to be found at all.
+			if tt.duplicate && !shareBand(a, b) {
+				t.Errorf("duplicates share no band")
+			}
+		})
+	}
+}
+
+func TestSimilarityMismatchedSignatures(t *testing.T) {
+	if got := similarity(nil, nil); got != 0 {
+		t.Errorf("similarity of empty signatures = %v, want 0", got)
+	}
+	if got := similarity([]uint64{1, 2}, []uint64{1}); got != 0 {
+		t.Errorf("similarity of mismatched signatures = %v, want 0", got)
+	}
+}
+
+func TestEncodeDecode(t *testing.T) {
+	sig := newTestDetector().signature(words("w", 0, 50))
+	got := decode(encode(sig))
+	if len(got) != len(sig) {
+		t.Fatalf("decoded %d hashes, want %d", len(got), len(sig))
+	}
+	for i := range sig {
+		if got[i] != sig[i] {
+			t.Fatalf("hash %d = %x, want %x", i, got[i], sig[i])
+		}
+	}
+}
+
+func reversed(s string) string {
+	w := strings.Fields(s)
+	for i, j := 0, len(w)-1; i < j; i, j = i+1, j-1 {
+		w[i], w[j] = w[j], w[i]
+	}
+	return strings.Join(w, " ")
+}
+
+func shareBand(a, b []uint64) bool {
+	ha, hb := bandHashes(a), bandHashes(b)
+	for i := range ha {
+		if ha[i] == hb[i] {
+			return true
+		}
+	}
+	return false
+}
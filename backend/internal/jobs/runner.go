@@ -0,0 +1,86 @@
+package jobs
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Worker is a long-running background task. Run must return once ctx is
+// cancelled, after finishing any work that has to survive shutdown.
+type Worker interface {
+	Run(ctx context.Context)
+}
+
+// WorkerFunc adapts a function to the Worker interface.
+type WorkerFunc func(ctx context.Context)
+
+func (f WorkerFunc) Run(ctx context.Context) {
+	f(ctx)
+}
+
+type Runner struct {
+	workers []Worker
+	wg      sync.WaitGroup
+	cancel  context.CancelFunc
+}
+
+func NewRunner() *Runner {
+	return &Runner{}
+}
+
+func (r *Runner) Add(w Worker) {
+	r.workers = append(r.workers, w)
+}
+
+func (r *Runner) Start(parent context.Context) {
+	ctx, cancel := context.WithCancel(parent)
+	r.cancel = cancel
+
+	for _, w := range r.workers {
+		r.wg.Add(1)
+		go func(w Worker) {
+			defer r.wg.Done()
+			w.Run(ctx)
+		}(w)
+	}
+}
+
+// Stop cancels all workers and waits for them to return, or for ctx to
+// expire, whichever comes first.
+func (r *Runner) Stop(ctx context.Context) error {
+	if r.cancel != nil {
+		r.cancel()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		r.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Every returns a worker that calls fn on each tick of interval until the
+// context is cancelled.
+func Every(interval time.Duration, fn func(ctx context.Context)) Worker {
+	return WorkerFunc(func(ctx context.Context) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				fn(ctx)
+			}
+		}
+	})
+}
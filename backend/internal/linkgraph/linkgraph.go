@@ -0,0 +1,184 @@
+// Package linkgraph records which posts link to which, so backlinks can be
+// listed and links to deleted, unpublished or renamed posts reported.
+package linkgraph
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/yairfalse/modern-cloud-app/backend/internal/config"
+	"github.com/yairfalse/modern-cloud-app/backend/internal/database/models"
+	"github.com/yairfalse/modern-cloud-app/backend/pkg/markdown"
+)
+
+// postPath matches the URLs a post is served under, by ID or slug.
+var postPath = regexp.MustCompile(`^(?:/api/v1)?/posts/([^/]+)/?$`)
+
+type Graph struct {
+	renderer *markdown.Renderer
+	hosts    map[string]bool
+}
+
+func NewGraph(cfg config.LinksConfig) *Graph {
+	hosts := make(map[string]bool, len(cfg.Hosts))
+	for _, host := range cfg.Hosts {
+		hosts[strings.ToLower(host)] = true
+	}
+	return &Graph{
+		renderer: markdown.NewRenderer(),
+		hosts:    hosts,
+	}
+}
+
+// WithHost returns a copy of the graph that also treats links to host as
+// internal, such as the site an import came from.
+func (g *Graph) WithHost(host string) *Graph {
+	hosts := make(map[string]bool, len(g.hosts)+1)
+	for h := range g.hosts {
+		hosts[h] = true
+	}
+	hosts[strings.ToLower(host)] = true
+	return &Graph{renderer: g.renderer, hosts: hosts}
+}
+
+// Update replaces the outgoing links of post with those in its content.
+// post must have its ID, Locale and Content loaded.
+func (g *Graph) Update(tx *gorm.DB, post *models.Post) error {
+	if err := tx.Where("source_post_id = ?", post.ID).Delete(&models.PostLink{}).Error; err != nil {
+		return err
+	}
+
+	seen := make(map[string]bool)
+	var edges []models.PostLink
+	for _, href := range g.renderer.Links(post.Content) {
+		if seen[href] {
+			continue
+		}
+		seen[href] = true
+
+		edge, ok, err := g.resolve(tx, post, href)
+		if err != nil {
+			return err
+		}
+		if ok {
+			edges = append(edges, edge)
+		}
+	}
+	if len(edges) == 0 {
+		return nil
+	}
+	return tx.Create(&edges).Error
+}
+
+// Attach points links that name post's current slug at post. It is called
+// when a post is created or takes a new slug, since the current slug wins
+// over older posts that once had it.
+func Attach(tx *gorm.DB, post *models.Post) error {
+	return tx.Model(&models.PostLink{}).
+		Where("target_slug = ? AND target_locale = ?", post.Slug, post.Locale).
+		Update("target_post_id", post.ID).Error
+}
+
+// Remove drops a post from the graph when it is purged. Links to it are
+// kept, without a target, so they show up as broken.
+func Remove(tx *gorm.DB, postID uuid.UUID) error {
+	if err := tx.Where("source_post_id = ?", postID).Delete(&models.PostLink{}).Error; err != nil {
+		return err
+	}
+	return tx.Model(&models.PostLink{}).
+		Where("target_post_id = ?", postID).
+		Update("target_post_id", nil).Error
+}
+
+// resolve turns href into an edge if it points at a post. Links by slug or
+// ID are kept even when nothing matches, so they can be reported; other
+// paths are only internal if a legacy URL maps them to a post.
+func (g *Graph) resolve(tx *gorm.DB, source *models.Post, href string) (models.PostLink, bool, error) {
+	edge := models.PostLink{SourcePostID: source.ID, Href: href}
+
+	u, err := url.Parse(strings.TrimSpace(href))
+	if err != nil {
+		return edge, false, nil
+	}
+	if u.Scheme != "" || u.Host != "" {
+		if (u.Scheme != "http" && u.Scheme != "https") || !g.hosts[strings.ToLower(u.Hostname())] {
+			return edge, false, nil
+		}
+	} else if !strings.HasPrefix(u.Path, "/") {
+		return edge, false, nil
+	}
+
+	if m := postPath.FindStringSubmatch(u.Path); m != nil {
+		if id, err := uuid.Parse(m[1]); err == nil {
+			var target models.Post
+			err := tx.Unscoped().Select("id").Where("id = ?", id).Take(&target).Error
+			if err != nil && err != gorm.ErrRecordNotFound {
+				return edge, false, err
+			}
+			if err == nil {
+				edge.TargetPostID = &target.ID
+			}
+			return edge, true, nil
+		}
+
+		edge.TargetSlug = m[1]
+		edge.TargetLocale = u.Query().Get("lang")
+		if edge.TargetLocale == "" {
+			edge.TargetLocale = source.Locale
+		}
+		target, err := resolveSlug(tx, edge.TargetLocale, edge.TargetSlug, u.Query().Get("lang") == "")
+		if err != nil {
+			return edge, false, err
+		}
+		edge.TargetPostID = target
+		return edge, true, nil
+	}
+
+	var legacy models.LegacyURL
+	err = tx.Select("post_id").Where("path = ?", models.LegacyPath(href)).Take(&legacy).Error
+	if err == gorm.ErrRecordNotFound {
+		return edge, false, nil
+	}
+	if err != nil {
+		return edge, false, err
+	}
+	edge.TargetPostID = &legacy.PostID
+	return edge, true, nil
+}
+
+// resolveSlug finds the post a slug link leads to: the post with that slug
+// in locale, then, if the link did not name a locale, in any locale, and
+// finally the post that used to have it. Deleted posts are included so the
+// link can be reported as pointing at one.
+func resolveSlug(tx *gorm.DB, locale, slug string, anyLocale bool) (*uuid.UUID, error) {
+	var ids []uuid.UUID
+	if err := tx.Unscoped().Model(&models.Post{}).
+		Where("slug = ? AND locale = ?", slug, locale).
+		Limit(1).Pluck("id", &ids).Error; err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 && anyLocale {
+		if err := tx.Unscoped().Model(&models.Post{}).
+			Where("slug = ?", slug).
+			Order("created_at ASC").
+			Limit(1).Pluck("id", &ids).Error; err != nil {
+			return nil, err
+		}
+	}
+	if len(ids) == 0 {
+		if err := tx.Model(&models.PostSlug{}).
+			Where("slug = ? AND locale = ?", slug, locale).
+			Order("created_at DESC").
+			Limit(1).Pluck("post_id", &ids).Error; err != nil {
+			return nil, err
+		}
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	return &ids[0], nil
+}
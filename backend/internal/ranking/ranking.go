@@ -0,0 +1,98 @@
+package ranking
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/yairfalse/modern-cloud-app/backend/internal/config"
+	"github.com/yairfalse/modern-cloud-app/backend/internal/database/models"
+)
+
+// Event weights for the trending score. A view is the unit.
+const (
+	viewWeight    = 1.0
+	likeWeight    = 4.0
+	commentWeight = 6.0
+)
+
+// Popularity weights lifetime engagement the same way, without decay.
+const (
+	popularLikeWeight    = 5
+	popularCommentWeight = 10
+)
+
+const (
+	defaultInterval = 10 * time.Minute
+	defaultHalfLife = 24 * time.Hour
+)
+
+// Scorer periodically recomputes posts.trending_score and
+// posts.popularity_score.
+type Scorer struct {
+	db  *gorm.DB
+	cfg config.RankingConfig
+}
+
+func NewScorer(db *gorm.DB, cfg config.RankingConfig) *Scorer {
+	if cfg.Interval <= 0 {
+		cfg.Interval = defaultInterval
+	}
+	// The half-life divides event ages in Recompute.
+	if cfg.HalfLife <= 0 {
+		cfg.HalfLife = defaultHalfLife
+	}
+	return &Scorer{db: db, cfg: cfg}
+}
+
+func (s *Scorer) Run(ctx context.Context) {
+	if err := s.Recompute(); err != nil {
+		log.Printf("ranking: recompute failed: %v", err)
+	}
+
+	ticker := time.NewTicker(s.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.Recompute(); err != nil {
+				log.Printf("ranking: recompute failed: %v", err)
+			}
+		}
+	}
+}
+
+// Recompute scores every post in one statement. The trending score sums
+// recent view, like and comment events, each halving in weight every
+// half-life.
+func (s *Scorer) Recompute() error {
+	since := time.Now().UTC().Add(-s.cfg.Window)
+
+	return s.db.Exec(`
+		UPDATE posts SET
+			trending_score = COALESCE(recent.score, 0),
+			popularity_score = posts.view_count + ?::int * posts.like_count + ?::int * posts.comment_count
+		FROM posts p
+		LEFT JOIN (
+			SELECT post_id, SUM(
+				CASE type WHEN ? THEN ?::float8 WHEN ? THEN ?::float8 WHEN ? THEN ?::float8 ELSE 0 END
+				* power(0.5, EXTRACT(EPOCH FROM (now() - occurred_at)) / ?::float8)
+			) AS score
+			FROM post_events
+			WHERE occurred_at > ?
+			GROUP BY post_id
+		) recent ON recent.post_id = p.id
+		WHERE posts.id = p.id AND posts.deleted_at IS NULL`,
+		popularLikeWeight, popularCommentWeight,
+		models.PostEventView, viewWeight,
+		models.PostEventLike, likeWeight,
+		models.PostEventComment, commentWeight,
+		s.cfg.HalfLife.Seconds(),
+		since,
+	).Error
+}
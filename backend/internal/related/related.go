@@ -0,0 +1,307 @@
+package related
+
+import (
+	"context"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/yairfalse/modern-cloud-app/backend/internal/config"
+	"github.com/yairfalse/modern-cloud-app/backend/internal/database/models"
+)
+
+// Score weights for each signal. Tag overlap is weighted per tag by inverse
+// document frequency, so sharing a rare tag counts more than a common one.
+const (
+	sameAuthorWeight = 1.0
+	sameSeriesWeight = 1.5
+	textWeight       = 3.0
+
+	// textCandidates bounds how many recent posts are compared on text alone.
+	textCandidates = 200
+	queueSize      = 256
+
+	defaultRefreshInterval = 6 * time.Hour
+)
+
+type Engine struct {
+	db         *gorm.DB
+	maxResults int
+	interval   time.Duration
+	queue      chan uuid.UUID
+}
+
+type candidate struct {
+	ID       uuid.UUID
+	Title    string
+	Excerpt  string
+	AuthorID uuid.UUID
+}
+
+func NewEngine(db *gorm.DB, cfg config.RelatedConfig) *Engine {
+	interval := cfg.RefreshInterval
+	if interval <= 0 {
+		interval = defaultRefreshInterval
+	}
+	return &Engine{
+		db:         db,
+		maxResults: cfg.MaxResults,
+		interval:   interval,
+		queue:      make(chan uuid.UUID, queueSize),
+	}
+}
+
+// Enqueue schedules a recompute for a post and the posts sharing its tags.
+// It never blocks; if the queue is full the periodic refresh catches up.
+func (e *Engine) Enqueue(postID uuid.UUID) {
+	select {
+	case e.queue <- postID:
+	default:
+	}
+}
+
+func (e *Engine) Run(ctx context.Context) {
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case postID := <-e.queue:
+			e.refreshNeighbourhood(postID)
+		case <-ticker.C:
+			e.RefreshAll(ctx)
+		}
+	}
+}
+
+// RefreshAll recomputes recommendations for every published post.
+func (e *Engine) RefreshAll(ctx context.Context) {
+	var ids []uuid.UUID
+	if err := e.db.Model(&models.Post{}).
+		Where("status = ?", models.PostStatusPublished).
+		Pluck("id", &ids).Error; err != nil {
+		log.Printf("related: failed to list posts: %v", err)
+		return
+	}
+
+	for _, id := range ids {
+		if ctx.Err() != nil {
+			return
+		}
+		if err := e.Refresh(id); err != nil {
+			log.Printf("related: failed to refresh post %s: %v", id, err)
+		}
+	}
+}
+
+func (e *Engine) refreshNeighbourhood(postID uuid.UUID) {
+	if err := e.Refresh(postID); err != nil {
+		log.Printf("related: failed to refresh post %s: %v", postID, err)
+		return
+	}
+
+	var neighbours []uuid.UUID
+	e.db.Table("post_tags").
+		Distinct("post_id").
+		Where("tag_id IN (SELECT tag_id FROM post_tags WHERE post_id = ?) AND post_id <> ?", postID, postID).
+		Limit(100).
+		Pluck("post_id", &neighbours)
+
+	for _, id := range neighbours {
+		if err := e.Refresh(id); err != nil {
+			log.Printf("related: failed to refresh post %s: %v", id, err)
+		}
+	}
+}
+
+// Refresh recomputes and stores the related posts for a single post.
+func (e *Engine) Refresh(postID uuid.UUID) error {
+	var post models.Post
+	err := e.db.Select("id", "title", "excerpt", "author_id", "status").
+		First(&post, "id = ?", postID).Error
+	if err == gorm.ErrRecordNotFound || (err == nil && post.Status != models.PostStatusPublished) {
+		return e.db.Where("post_id = ?", postID).Delete(&models.RelatedPost{}).Error
+	}
+	if err != nil {
+		return err
+	}
+
+	scores := make(map[uuid.UUID]float64)
+
+	if err := e.scoreTags(post.ID, scores); err != nil {
+		return err
+	}
+
+	var sameAuthor []uuid.UUID
+	e.published().Where("author_id = ? AND id <> ?", post.AuthorID, post.ID).Pluck("id", &sameAuthor)
+	for _, id := range sameAuthor {
+		scores[id] += sameAuthorWeight
+	}
+
+	var sameSeries []uuid.UUID
+	e.published().
+		Where("id <> ? AND id IN (SELECT sp.post_id FROM series_posts sp JOIN series_posts own ON own.series_id = sp.series_id WHERE own.post_id = ?)", post.ID, post.ID).
+		Pluck("id", &sameSeries)
+	for _, id := range sameSeries {
+		scores[id] += sameSeriesWeight
+	}
+
+	if err := e.scoreText(post, scores); err != nil {
+		return err
+	}
+
+	return e.store(post.ID, scores)
+}
+
+func (e *Engine) published() *gorm.DB {
+	return e.db.Model(&models.Post{}).Where("status = ?", models.PostStatusPublished)
+}
+
+func (e *Engine) scoreTags(postID uuid.UUID, scores map[uuid.UUID]float64) error {
+	var total int64
+	if err := e.published().Count(&total).Error; err != nil {
+		return err
+	}
+
+	var frequencies []struct {
+		TagID uuid.UUID
+		Count int64
+	}
+	if err := e.db.Table("post_tags").
+		Select("post_tags.tag_id, COUNT(*) AS count").
+		Joins("JOIN posts ON posts.id = post_tags.post_id AND posts.deleted_at IS NULL").
+		Where("posts.status = ? AND post_tags.tag_id IN (SELECT tag_id FROM post_tags WHERE post_id = ?)", models.PostStatusPublished, postID).
+		Group("post_tags.tag_id").
+		Scan(&frequencies).Error; err != nil {
+		return err
+	}
+
+	weights := make(map[uuid.UUID]float64, len(frequencies))
+	for _, f := range frequencies {
+		weights[f.TagID] = math.Log(float64(total+1)/float64(f.Count+1)) + 1
+	}
+
+	var shared []struct {
+		PostID uuid.UUID
+		TagID  uuid.UUID
+	}
+	if err := e.db.Table("post_tags").
+		Select("post_tags.post_id, post_tags.tag_id").
+		Joins("JOIN posts ON posts.id = post_tags.post_id AND posts.deleted_at IS NULL").
+		Where("posts.status = ? AND post_tags.post_id <> ? AND post_tags.tag_id IN (SELECT tag_id FROM post_tags WHERE post_id = ?)", models.PostStatusPublished, postID, postID).
+		Scan(&shared).Error; err != nil {
+		return err
+	}
+
+	for _, s := range shared {
+		scores[s.PostID] += weights[s.TagID]
+	}
+	return nil
+}
+
+// scoreText adds title/excerpt similarity for posts already scored plus the
+// most recent published posts.
+func (e *Engine) scoreText(post models.Post, scores map[uuid.UUID]float64) error {
+	ids := make([]uuid.UUID, 0, len(scores))
+	for id := range scores {
+		ids = append(ids, id)
+	}
+
+	var candidates []candidate
+	query := e.published().Select("id", "title", "excerpt", "author_id").Where("id <> ?", post.ID)
+	if len(ids) > 0 {
+		query = query.Where("id IN ? OR id IN (?)", ids,
+			e.published().Select("id").Order("published_at DESC NULLS LAST").Limit(textCandidates))
+	} else {
+		query = query.Order("published_at DESC NULLS LAST").Limit(textCandidates)
+	}
+	if err := query.Find(&candidates).Error; err != nil {
+		return err
+	}
+
+	source := tokenize(post.Title + " " + post.Excerpt)
+	if len(source) == 0 {
+		return nil
+	}
+
+	for _, c := range candidates {
+		if similarity := jaccard(source, tokenize(c.Title+" "+c.Excerpt)); similarity > 0 {
+			scores[c.ID] += similarity * textWeight
+		}
+	}
+	return nil
+}
+
+func (e *Engine) store(postID uuid.UUID, scores map[uuid.UUID]float64) error {
+	rows := make([]models.RelatedPost, 0, len(scores))
+	now := time.Now().UTC()
+	for id, score := range scores {
+		if id == postID || score <= 0 {
+			continue
+		}
+		rows = append(rows, models.RelatedPost{
+			PostID:        postID,
+			RelatedPostID: id,
+			Score:         score,
+			ComputedAt:    now,
+		})
+	}
+
+	sort.Slice(rows, func(i, j int) bool { return rows[i].Score > rows[j].Score })
+	if len(rows) > e.maxResults {
+		rows = rows[:e.maxResults]
+	}
+
+	return e.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("post_id = ?", postID).Delete(&models.RelatedPost{}).Error; err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		return tx.Create(&rows).Error
+	})
+}
+
+func tokenize(text string) map[string]struct{} {
+	tokens := make(map[string]struct{})
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if len([]rune(word)) < 3 || stopWords[word] {
+			continue
+		}
+		tokens[word] = struct{}{}
+	}
+	return tokens
+}
+
+func jaccard(a, b map[string]struct{}) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+
+	intersection := 0
+	for token := range a {
+		if _, ok := b[token]; ok {
+			intersection++
+		}
+	}
+	union := len(a) + len(b) - intersection
+	return float64(intersection) / float64(union)
+}
+
+var stopWords = map[string]bool{
+	"the": true, "and": true, "for": true, "with": true, "that": true,
+	"this": true, "from": true, "your": true, "you": true, "are": true,
+	"how": true, "what": true, "why": true, "into": true, "about": true,
+	"not": true, "but": true, "can": true, "all": true, "our": true,
+}
@@ -0,0 +1,89 @@
+// Package scheduling publishes scheduled posts once their time comes.
+package scheduling
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/yairfalse/modern-cloud-app/backend/internal/config"
+	"github.com/yairfalse/modern-cloud-app/backend/internal/database/models"
+	"github.com/yairfalse/modern-cloud-app/backend/internal/related"
+)
+
+const defaultInterval = time.Minute
+
+// Publisher moves scheduled posts whose scheduled_at has passed to
+// published.
+type Publisher struct {
+	db       *gorm.DB
+	related  *related.Engine
+	interval time.Duration
+}
+
+func NewPublisher(db *gorm.DB, cfg config.SchedulingConfig, relatedEngine *related.Engine) *Publisher {
+	interval := cfg.Interval
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+	return &Publisher{db: db, related: relatedEngine, interval: interval}
+}
+
+func (p *Publisher) Run(ctx context.Context) {
+	p.publish()
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.publish()
+		}
+	}
+}
+
+func (p *Publisher) publish() {
+	ids, err := p.PublishDue(time.Now().UTC())
+	if err != nil {
+		log.Printf("scheduling: failed to publish due posts: %v", err)
+		return
+	}
+	for _, id := range ids {
+		p.related.Enqueue(id)
+	}
+}
+
+// PublishDue publishes every scheduled post due at now and returns their
+// IDs. A post keeps an earlier publish date, and otherwise is dated by its
+// schedule rather than by when this ran.
+func (p *Publisher) PublishDue(now time.Time) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := p.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Post{}).
+			Where("status = ? AND scheduled_at <= ?", models.PostStatusScheduled, now).
+			Pluck("id", &ids).Error; err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+		return tx.Model(&models.Post{}).
+			Where("id IN ? AND status = ?", ids, models.PostStatusScheduled).
+			UpdateColumns(map[string]interface{}{
+				"status":       models.PostStatusPublished,
+				"published_at": gorm.Expr("COALESCE(published_at, scheduled_at)"),
+				"version":      gorm.Expr("version + 1"),
+				"updated_at":   now,
+			}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
@@ -0,0 +1,177 @@
+// Package trash restores and permanently removes soft-deleted posts and
+// comments.
+package trash
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/yairfalse/modern-cloud-app/backend/internal/config"
+	"github.com/yairfalse/modern-cloud-app/backend/internal/database/models"
+	"github.com/yairfalse/modern-cloud-app/backend/internal/duplicates"
+	"github.com/yairfalse/modern-cloud-app/backend/internal/jobs"
+	"github.com/yairfalse/modern-cloud-app/backend/internal/linkgraph"
+)
+
+const (
+	purgeBatchSize       = 100
+	defaultPurgeInterval = time.Hour
+)
+
+// RestorePost takes a post out of the trash and recomputes its counters from
+// the rows that are live now, since deleting comments of a trashed post does
+// not update them. It must be called inside a transaction.
+func RestorePost(tx *gorm.DB, postID uuid.UUID) error {
+	if err := tx.Unscoped().Model(&models.Post{}).Where("id = ?", postID).Update("deleted_at", nil).Error; err != nil {
+		return err
+	}
+	return tx.Model(&models.Post{}).Where("id = ?", postID).UpdateColumns(map[string]interface{}{
+		"comment_count": tx.Model(&models.Comment{}).Select("COUNT(*)").Where("post_id = ? AND held = ?", postID, false),
+		"like_count":    tx.Model(&models.Like{}).Select("COUNT(*)").Where("post_id = ?", postID),
+	}).Error
+}
+
+// PurgePost hard-deletes a post and every row that refers to it. It must be
+// called inside a transaction.
+func PurgePost(tx *gorm.DB, postID uuid.UUID) error {
+	tx = tx.Session(&gorm.Session{SkipHooks: true})
+
+	commentIDs := tx.Unscoped().Model(&models.Comment{}).Select("id").Where("post_id = ?", postID)
+	var comments []uuid.UUID
+	if err := tx.Unscoped().Model(&models.Comment{}).Where("post_id = ?", postID).Pluck("id", &comments).Error; err != nil {
+		return err
+	}
+	if err := duplicates.Remove(tx, duplicates.KindComment, comments...); err != nil {
+		return err
+	}
+	if err := duplicates.Remove(tx, duplicates.KindPost, postID); err != nil {
+		return err
+	}
+	if err := tx.Where("post_id = ? OR comment_id IN (?)", postID, commentIDs).Delete(&models.Like{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Where("post_id = ?", postID).Delete(&models.Comment{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("post_id = ? OR related_post_id = ?", postID, postID).Delete(&models.RelatedPost{}).Error; err != nil {
+		return err
+	}
+	if err := linkgraph.Remove(tx, postID); err != nil {
+		return err
+	}
+
+	for _, table := range []string{"post_tags", "post_categories"} {
+		if err := tx.Exec("DELETE FROM "+table+" WHERE post_id = ?", postID).Error; err != nil {
+			return err
+		}
+	}
+
+	dependents := []interface{}{
+		&models.PostSlug{},
+		&models.PreviewLink{},
+		&models.PostReview{},
+		&models.Annotation{},
+		&models.PostAutosave{},
+		&models.PostCollaborator{},
+		&models.SeriesPost{},
+		&models.PostEvent{},
+		&models.PostDailyStat{},
+		&models.PostReferrerStat{},
+		&models.Bookmark{},
+		&models.ReadingProgress{},
+		&models.LegacyURL{},
+	}
+	for _, model := range dependents {
+		if err := tx.Unscoped().Where("post_id = ?", postID).Delete(model).Error; err != nil {
+			return err
+		}
+	}
+
+	return tx.Unscoped().Where("id = ?", postID).Delete(&models.Post{}).Error
+}
+
+// PurgeComment hard-deletes a soft-deleted comment and its likes. Replies
+// are kept and move up to the purged comment's parent.
+func PurgeComment(tx *gorm.DB, comment *models.Comment) error {
+	if err := duplicates.Remove(tx, duplicates.KindComment, comment.ID); err != nil {
+		return err
+	}
+	if err := tx.Where("comment_id = ?", comment.ID).Delete(&models.Like{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Model(&models.Comment{}).
+		Where("parent_id = ?", comment.ID).
+		UpdateColumn("parent_id", comment.ParentID).Error; err != nil {
+		return err
+	}
+	return tx.Unscoped().Session(&gorm.Session{SkipHooks: true}).
+		Where("id = ?", comment.ID).Delete(&models.Comment{}).Error
+}
+
+// NewPurger returns a worker that purges posts and comments that have been
+// in the trash for longer than the configured retention.
+func NewPurger(db *gorm.DB, cfg config.TrashConfig) jobs.Worker {
+	interval := cfg.PurgeInterval
+	if interval <= 0 {
+		interval = defaultPurgeInterval
+	}
+	return jobs.Every(interval, func(ctx context.Context) {
+		cutoff := time.Now().Add(-cfg.Retention)
+		purgeExpiredPosts(ctx, db, cutoff)
+		purgeExpiredComments(ctx, db, cutoff)
+	})
+}
+
+func purgeExpiredPosts(ctx context.Context, db *gorm.DB, cutoff time.Time) {
+	for ctx.Err() == nil {
+		var ids []uuid.UUID
+		if err := db.Unscoped().Model(&models.Post{}).
+			Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).
+			Limit(purgeBatchSize).
+			Pluck("id", &ids).Error; err != nil {
+			log.Printf("trash: failed to list expired posts: %v", err)
+			return
+		}
+		if len(ids) == 0 {
+			return
+		}
+
+		for _, id := range ids {
+			if err := db.Transaction(func(tx *gorm.DB) error {
+				return PurgePost(tx, id)
+			}); err != nil {
+				log.Printf("trash: failed to purge post %s: %v", id, err)
+				return
+			}
+		}
+	}
+}
+
+func purgeExpiredComments(ctx context.Context, db *gorm.DB, cutoff time.Time) {
+	for ctx.Err() == nil {
+		var comments []models.Comment
+		if err := db.Unscoped().Select("id", "parent_id").
+			Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).
+			Limit(purgeBatchSize).
+			Find(&comments).Error; err != nil {
+			log.Printf("trash: failed to list expired comments: %v", err)
+			return
+		}
+		if len(comments) == 0 {
+			return
+		}
+
+		for i := range comments {
+			if err := db.Transaction(func(tx *gorm.DB) error {
+				return PurgeComment(tx, &comments[i])
+			}); err != nil {
+				log.Printf("trash: failed to purge comment %s: %v", comments[i].ID, err)
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,229 @@
+package views
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/yairfalse/modern-cloud-app/backend/internal/config"
+	"github.com/yairfalse/modern-cloud-app/backend/internal/database/models"
+)
+
+const (
+	defaultFlushInterval = 30 * time.Second
+	defaultDedupeWindow  = 30 * time.Minute
+
+	// maxRetainedEvents bounds the view events kept in memory while flushes
+	// are failing. The oldest are dropped first.
+	maxRetainedEvents = 100000
+)
+
+// Viewer identifies who is reading a post. Anonymous viewers are identified
+// by a hash of their IP and user agent, never by the raw values.
+type Viewer struct {
+	UserID    uuid.UUID
+	IP        string
+	UserAgent string
+	Referrer  string
+
+	// Editor is set when the viewer is the post's author or a collaborator.
+	Editor bool
+}
+
+// Counter aggregates post views in memory, dropping repeat views from the
+// same viewer within a window, and writes the totals in batches together
+// with the raw view events used for analytics.
+type Counter struct {
+	db            *gorm.DB
+	window        time.Duration
+	flushInterval time.Duration
+
+	mu      sync.Mutex
+	seen    map[string]time.Time
+	pending map[uuid.UUID]int
+	events  []models.PostEvent
+}
+
+func NewCounter(db *gorm.DB, cfg config.ViewsConfig) *Counter {
+	window := cfg.DedupeWindow
+	if window <= 0 {
+		window = defaultDedupeWindow
+	}
+	flushInterval := cfg.FlushInterval
+	if flushInterval <= 0 {
+		flushInterval = defaultFlushInterval
+	}
+	return &Counter{
+		db:            db,
+		window:        window,
+		flushInterval: flushInterval,
+		seen:          make(map[string]time.Time),
+		pending:       make(map[uuid.UUID]int),
+	}
+}
+
+// Record counts a view of postID unless it comes from a crawler, from one of
+// the post's editors, or from a viewer already counted in the current window.
+// It reports whether the view was counted.
+func (c *Counter) Record(postID uuid.UUID, viewer Viewer) bool {
+	if IsBot(viewer.UserAgent) || viewer.Editor {
+		return false
+	}
+
+	now := time.Now()
+	key := postID.String() + ":" + c.viewerKey(viewer, now)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if expires, ok := c.seen[key]; ok && now.Before(expires) {
+		return false
+	}
+	c.seen[key] = now.Add(c.window)
+	c.pending[postID]++
+
+	event := models.NewPostEvent(postID, models.PostEventView)
+	event.ViewerHash = dailyViewerHash(viewer, event.Day)
+	event.Referrer = ReferrerDomain(viewer.Referrer)
+	c.events = append(c.events, event)
+	return true
+}
+
+// Run flushes pending counts every flush interval, and once more when ctx is
+// cancelled so no views are lost on shutdown.
+func (c *Counter) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			c.Flush()
+			return
+		case <-ticker.C:
+			c.Flush()
+		}
+	}
+}
+
+// Flush writes all pending increments in a single transaction. On failure
+// the increments are kept for the next flush, along with up to
+// maxRetainedEvents view events.
+func (c *Counter) Flush() {
+	c.mu.Lock()
+	pending := c.pending
+	c.pending = make(map[uuid.UUID]int)
+	events := c.events
+	c.events = nil
+
+	now := time.Now()
+	for key, expires := range c.seen {
+		if now.After(expires) {
+			delete(c.seen, key)
+		}
+	}
+	c.mu.Unlock()
+
+	if len(pending) == 0 && len(events) == 0 {
+		return
+	}
+
+	err := c.db.Transaction(func(tx *gorm.DB) error {
+		for postID, count := range pending {
+			if err := tx.Model(&models.Post{}).Where("id = ?", postID).
+				UpdateColumn("view_count", gorm.Expr("view_count + ?", count)).Error; err != nil {
+				return err
+			}
+		}
+		if len(events) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(&events, 500).Error
+	})
+	if err != nil {
+		log.Printf("views: failed to flush %d posts: %v", len(pending), err)
+
+		c.mu.Lock()
+		for postID, count := range pending {
+			c.pending[postID] += count
+		}
+		c.events = append(events, c.events...)
+		if dropped := len(c.events) - maxRetainedEvents; dropped > 0 {
+			c.events = c.events[dropped:]
+			log.Printf("views: dropped %d view events after failed flushes", dropped)
+		}
+		c.mu.Unlock()
+	}
+}
+
+// viewerKey returns the dedupe identity for a viewer. For anonymous viewers
+// the hash includes the window start so the key rotates with each window.
+func (c *Counter) viewerKey(viewer Viewer, now time.Time) string {
+	if viewer.UserID != uuid.Nil {
+		return "u:" + viewer.UserID.String()
+	}
+
+	bucket := now.Truncate(c.window).Unix()
+	sum := sha256.Sum256([]byte(viewer.IP + "|" + viewer.UserAgent + "|" + strconv.FormatInt(bucket, 10)))
+	return "a:" + hex.EncodeToString(sum[:16])
+}
+
+// dailyViewerHash identifies a viewer for unique-viewer counts within a
+// single UTC day.
+func dailyViewerHash(viewer Viewer, day time.Time) string {
+	identity := viewer.IP + "|" + viewer.UserAgent
+	if viewer.UserID != uuid.Nil {
+		identity = viewer.UserID.String()
+	}
+	sum := sha256.Sum256([]byte(identity + "|" + day.Format("2006-01-02")))
+	return hex.EncodeToString(sum[:])
+}
+
+// ReferrerDomain reduces a Referer header to its host, without a "www."
+// prefix. It returns "" for missing or unparsable values.
+func ReferrerDomain(referrer string) string {
+	if referrer == "" {
+		return ""
+	}
+	u, err := url.Parse(referrer)
+	if err != nil || u.Hostname() == "" {
+		return ""
+	}
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	if len(host) > 255 {
+		return ""
+	}
+	return host
+}
+
+var botSignatures = []string{
+	"bot", "crawler", "spider", "slurp", "crawl", "fetcher",
+	"facebookexternalhit", "embedly", "quora link preview", "whatsapp",
+	"headlesschrome", "phantomjs", "lighthouse", "pingdom", "uptime",
+	"curl/", "wget/", "python-requests", "go-http-client", "httpclient",
+}
+
+// IsBot reports whether a user agent looks like a crawler, link previewer
+// or scripted client. An empty user agent is treated as a bot.
+func IsBot(userAgent string) bool {
+	if strings.TrimSpace(userAgent) == "" {
+		return true
+	}
+
+	ua := strings.ToLower(userAgent)
+	for _, signature := range botSignatures {
+		if strings.Contains(ua, signature) {
+			return true
+		}
+	}
+	return false
+}
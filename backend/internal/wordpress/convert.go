@@ -0,0 +1,67 @@
+package wordpress
+
+import (
+	"regexp"
+	"strings"
+
+	md "github.com/JohannesKaufmann/html-to-markdown"
+	"github.com/JohannesKaufmann/html-to-markdown/plugin"
+)
+
+var (
+	blockStart = regexp.MustCompile(`(?i)^<(p|div|h[1-6]|ul|ol|li|blockquote|pre|table|figure|hr|!--|img|iframe|address|dl)[\s>/]`)
+	caption    = regexp.MustCompile(`(?s)\[caption[^\]]*\](.*?)\[/caption\]`)
+	blankLines = regexp.MustCompile(`\n{3,}`)
+	preBlock   = regexp.MustCompile(`(?is)<pre[\s>].*?</pre>`)
+)
+
+func newConverter() *md.Converter {
+	converter := md.NewConverter("", true, &md.Options{
+		HeadingStyle:     "atx",
+		CodeBlockStyle:   "fenced",
+		BulletListMarker: "-",
+	})
+	converter.Use(plugin.GitHubFlavored())
+	return converter
+}
+
+// toMarkdown converts WordPress post HTML to Markdown. Classic-editor
+// content relies on WordPress adding paragraphs at render time, so blank
+// lines are turned into paragraphs first, as wpautop does.
+func toMarkdown(converter *md.Converter, html string) (string, error) {
+	html = caption.ReplaceAllString(html, "$1")
+	html = autop(html)
+
+	markdown, err := converter.ConvertString(html)
+	if err != nil {
+		return "", err
+	}
+	markdown = blankLines.ReplaceAllString(markdown, "\n\n")
+	return strings.TrimSpace(markdown) + "\n", nil
+}
+
+func autop(html string) string {
+	html = strings.ReplaceAll(html, "\r\n", "\n")
+
+	// Line breaks inside preformatted blocks are content, not paragraphs.
+	html = preBlock.ReplaceAllStringFunc(html, func(pre string) string {
+		return strings.ReplaceAll(pre, "\n", "\x00")
+	})
+
+	var out strings.Builder
+	for _, chunk := range strings.Split(html, "\n\n") {
+		chunk = strings.TrimSpace(chunk)
+		if chunk == "" {
+			continue
+		}
+		if blockStart.MatchString(chunk) {
+			out.WriteString(chunk)
+		} else {
+			out.WriteString("<p>")
+			out.WriteString(strings.ReplaceAll(chunk, "\n", "<br>\n"))
+			out.WriteString("</p>")
+		}
+		out.WriteString("\n\n")
+	}
+	return strings.ReplaceAll(out.String(), "\x00", "\n")
+}
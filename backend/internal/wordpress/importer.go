@@ -0,0 +1,861 @@
+package wordpress
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	md "github.com/JohannesKaufmann/html-to-markdown"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/yairfalse/modern-cloud-app/backend/internal/annotations"
+	"github.com/yairfalse/modern-cloud-app/backend/internal/database/models"
+	"github.com/yairfalse/modern-cloud-app/backend/internal/duplicates"
+	"github.com/yairfalse/modern-cloud-app/backend/internal/linkgraph"
+	"github.com/yairfalse/modern-cloud-app/backend/pkg/slugify"
+)
+
+// Record kinds stored in models.ImportRecord.
+const (
+	kindUser      = "user"
+	kindCommenter = "commenter"
+	kindCategory  = "category"
+	kindTag       = "tag"
+	kindPost      = "post"
+	kindComment   = "comment"
+)
+
+// unusablePassword is stored for imported accounts. It is not a valid bcrypt
+// hash, so no password matches it until one is set.
+const unusablePassword = "!"
+
+var errDryRun = errors.New("dry run")
+
+type Options struct {
+	// DryRun runs the whole import and then rolls it back.
+	DryRun bool
+	// Update overwrites posts that were imported before. By default they are
+	// left alone so local edits survive a re-run; new comments and legacy
+	// URLs are still added.
+	Update bool
+	// Source overrides the site URL that imported items are keyed by.
+	Source string
+	// Locale is the locale imported posts are written in. Slugs only have to
+	// be unique within it.
+	Locale string
+}
+
+type Stats struct {
+	Source   string         `json:"source"`
+	Created  map[string]int `json:"created"`
+	Updated  map[string]int `json:"updated"`
+	Skipped  map[string]int `json:"skipped"`
+	Warnings []string       `json:"warnings"`
+}
+
+type Importer struct {
+	db         *gorm.DB
+	converter  *md.Converter
+	links      *linkgraph.Graph
+	duplicates *duplicates.Detector
+}
+
+// run holds the state of one import inside its transaction.
+type run struct {
+	tx          *gorm.DB
+	converter   *md.Converter
+	duplicates  *duplicates.Detector
+	opts        Options
+	stats       *Stats
+	users       map[string]uuid.UUID
+	userIDs     map[string]uuid.UUID
+	categories  map[string]category
+	categoryIDs map[string]uuid.UUID
+	tagIDs      map[string]uuid.UUID
+	attachments map[string]string
+	// written holds the posts created or updated, which are added to the
+	// link graph and fingerprinted once every item has been imported.
+	written []models.Post
+}
+
+func NewImporter(db *gorm.DB, linkGraph *linkgraph.Graph, detector *duplicates.Detector) *Importer {
+	return &Importer{
+		db:         db,
+		converter:  newConverter(),
+		links:      linkGraph,
+		duplicates: detector,
+	}
+}
+
+// Import reads a WXR file and imports its authors, categories, tags, posts,
+// pages and comments in a single transaction. Items already imported from
+// the same site are matched through their import records, so re-running an
+// import does not create duplicates.
+func (im *Importer) Import(r io.Reader, opts Options) (*Stats, error) {
+	ch, err := parse(r)
+	if err != nil {
+		return nil, fmt.Errorf("parse WXR: %w", err)
+	}
+
+	source := opts.Source
+	if source == "" {
+		source = ch.siteURL()
+	}
+	source = strings.TrimRight(source, "/")
+	if source == "" {
+		return nil, errors.New("export has no site URL, pass a source")
+	}
+	if opts.Locale == "" {
+		return nil, errors.New("no locale given for imported posts")
+	}
+
+	stats := &Stats{
+		Source:  source,
+		Created: make(map[string]int),
+		Updated: make(map[string]int),
+		Skipped: make(map[string]int),
+	}
+
+	err = im.db.Transaction(func(tx *gorm.DB) error {
+		ir := &run{
+			tx:          tx,
+			converter:   im.converter,
+			duplicates:  im.duplicates,
+			opts:        opts,
+			stats:       stats,
+			users:       make(map[string]uuid.UUID),
+			userIDs:     make(map[string]uuid.UUID),
+			categories:  make(map[string]category),
+			categoryIDs: make(map[string]uuid.UUID),
+			tagIDs:      make(map[string]uuid.UUID),
+			attachments: make(map[string]string),
+		}
+		ir.opts.Source = source
+
+		for _, a := range ch.Authors {
+			if err := ir.importAuthor(a); err != nil {
+				return err
+			}
+		}
+		for _, c := range ch.Categories {
+			ir.categories[c.Nicename] = c
+		}
+		for _, c := range ch.Categories {
+			if _, err := ir.categoryID(c.Nicename, c.Name, nil); err != nil {
+				return err
+			}
+		}
+		for _, t := range ch.Tags {
+			if _, err := ir.tagID(t.Slug, t.Name); err != nil {
+				return err
+			}
+		}
+		for _, it := range ch.Items {
+			if it.PostType == "attachment" && it.AttachmentURL != "" {
+				ir.attachments[it.PostID] = it.AttachmentURL
+			}
+		}
+		for i := range ch.Items {
+			if err := ir.importItem(&ch.Items[i]); err != nil {
+				return fmt.Errorf("item %s (%q): %w", ch.Items[i].PostID, ch.Items[i].Title, err)
+			}
+		}
+		if err := ir.indexPosts(im.links); err != nil {
+			return err
+		}
+
+		if opts.DryRun {
+			return errDryRun
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errDryRun) {
+		return nil, err
+	}
+	return stats, nil
+}
+
+func (ir *run) importAuthor(a author) error {
+	login := strings.TrimSpace(a.Login)
+	if login == "" {
+		return nil
+	}
+
+	if id, ok := ir.lookup(kindUser, login); ok {
+		ir.remember(a, id)
+		ir.stats.Skipped[kindUser]++
+		return nil
+	}
+
+	var user models.User
+	err := gorm.ErrRecordNotFound
+	if email := strings.TrimSpace(a.Email); email != "" {
+		err = ir.tx.Where("LOWER(email) = LOWER(?)", email).First(&user).Error
+	}
+	switch {
+	case err == nil:
+		ir.stats.Skipped[kindUser]++
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		user = models.User{
+			Username:     ir.uniqueUsername(login),
+			Email:        placeholderEmail(a.Email, login),
+			PasswordHash: unusablePassword,
+			FirstName:    a.FirstName,
+			LastName:     a.LastName,
+			Role:         models.UserRoleAuthor,
+			IsActive:     true,
+		}
+		if user.FirstName == "" && user.LastName == "" {
+			user.FirstName = a.DisplayName
+		}
+		if err := ir.tx.Create(&user).Error; err != nil {
+			return err
+		}
+		ir.stats.Created[kindUser]++
+	default:
+		return err
+	}
+
+	ir.remember(a, user.ID)
+	return ir.record(kindUser, login, user.ID)
+}
+
+func (ir *run) remember(a author, id uuid.UUID) {
+	ir.users[a.Login] = id
+	if a.ID != "" {
+		ir.userIDs[a.ID] = id
+	}
+}
+
+// commenterID returns the user a comment is attributed to: the mapped author
+// for registered users, otherwise an inactive account per guest email.
+func (ir *run) commenterID(c comment) (uuid.UUID, error) {
+	if id, ok := ir.userIDs[c.UserID]; ok && c.UserID != "0" {
+		return id, nil
+	}
+
+	name := strings.TrimSpace(c.Author)
+	if name == "" {
+		name = "Anonymous"
+	}
+	key := strings.ToLower(strings.TrimSpace(c.AuthorEmail))
+	if key == "" {
+		key = "name:" + strings.ToLower(name)
+	}
+
+	if id, ok := ir.lookup(kindCommenter, key); ok {
+		return id, nil
+	}
+
+	var user models.User
+	err := gorm.ErrRecordNotFound
+	if email := strings.TrimSpace(c.AuthorEmail); email != "" {
+		err = ir.tx.Where("LOWER(email) = LOWER(?)", email).First(&user).Error
+	}
+	switch {
+	case err == nil:
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		username := slugify.Make(name)
+		if username == "" {
+			username = "commenter"
+		}
+		user = models.User{
+			Username:     ir.uniqueUsername(username),
+			Email:        placeholderEmail(c.AuthorEmail, "guest-"+shortHash(key)),
+			PasswordHash: unusablePassword,
+			FirstName:    name,
+			Role:         models.UserRoleAuthor,
+		}
+		if err := ir.tx.Create(&user).Error; err != nil {
+			return uuid.Nil, err
+		}
+		// IsActive defaults to true in the database, so it is cleared after
+		// the insert rather than through the zero value.
+		if err := ir.tx.Model(&user).Update("is_active", false).Error; err != nil {
+			return uuid.Nil, err
+		}
+		ir.stats.Created[kindCommenter]++
+	default:
+		return uuid.Nil, err
+	}
+
+	return user.ID, ir.record(kindCommenter, key, user.ID)
+}
+
+// categoryID resolves a category by nicename, importing its ancestors first.
+// Categories referenced only by posts are created at the root.
+func (ir *run) categoryID(nicename, name string, visiting map[string]bool) (uuid.UUID, error) {
+	nicename = strings.TrimSpace(nicename)
+	if id, ok := ir.categoryIDs[nicename]; ok {
+		return id, nil
+	}
+
+	if id, ok := ir.lookup(kindCategory, nicename); ok {
+		ir.categoryIDs[nicename] = id
+		ir.stats.Skipped[kindCategory]++
+		return id, nil
+	}
+
+	slug := slugify.Make(decodeSlug(nicename))
+	if slug == "" {
+		slug = slugify.Make(name)
+	}
+
+	var existing models.Category
+	err := ir.tx.Where("slug = ?", slug).First(&existing).Error
+	if err == nil {
+		ir.categoryIDs[nicename] = existing.ID
+		ir.stats.Skipped[kindCategory]++
+		return existing.ID, ir.record(kindCategory, nicename, existing.ID)
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return uuid.Nil, err
+	}
+
+	def, known := ir.categories[nicename]
+	if !known {
+		def = category{Nicename: nicename, Name: name}
+	}
+	if def.Name == "" {
+		def.Name = nicename
+	}
+
+	created := models.Category{
+		ID:          uuid.New(),
+		Name:        def.Name,
+		Slug:        slug,
+		Description: def.Description,
+	}
+	created.Path = "/" + created.ID.String() + "/"
+
+	if def.Parent != "" {
+		if visiting == nil {
+			visiting = make(map[string]bool)
+		}
+		if visiting[nicename] {
+			return uuid.Nil, fmt.Errorf("category %q is its own ancestor", nicename)
+		}
+		visiting[nicename] = true
+
+		parentID, err := ir.categoryID(def.Parent, "", visiting)
+		if err != nil {
+			return uuid.Nil, err
+		}
+		var parent models.Category
+		if err := ir.tx.First(&parent, "id = ?", parentID).Error; err != nil {
+			return uuid.Nil, err
+		}
+		created.ParentID = &parent.ID
+		created.Path = parent.ChildPath(created.ID)
+	}
+
+	if err := ir.tx.Create(&created).Error; err != nil {
+		return uuid.Nil, err
+	}
+	ir.categoryIDs[nicename] = created.ID
+	ir.stats.Created[kindCategory]++
+	return created.ID, ir.record(kindCategory, nicename, created.ID)
+}
+
+func (ir *run) tagID(nicename, name string) (uuid.UUID, error) {
+	nicename = strings.TrimSpace(nicename)
+	if id, ok := ir.tagIDs[nicename]; ok {
+		return id, nil
+	}
+
+	if id, ok := ir.lookup(kindTag, nicename); ok {
+		ir.tagIDs[nicename] = id
+		ir.stats.Skipped[kindTag]++
+		return id, nil
+	}
+
+	if name = strings.TrimSpace(name); name == "" {
+		name = decodeSlug(nicename)
+	}
+	slug := slugify.Make(decodeSlug(nicename))
+	if slug == "" {
+		slug = slugify.Make(name)
+	}
+
+	var tag models.Tag
+	err := ir.tx.Where("slug = ? OR name = ?", slug, name).First(&tag).Error
+	switch {
+	case err == nil:
+		ir.stats.Skipped[kindTag]++
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		tag = models.Tag{Name: name, Slug: slug}
+		if err := ir.tx.Create(&tag).Error; err != nil {
+			return uuid.Nil, err
+		}
+		ir.stats.Created[kindTag]++
+	default:
+		return uuid.Nil, err
+	}
+
+	ir.tagIDs[nicename] = tag.ID
+	return tag.ID, ir.record(kindTag, nicename, tag.ID)
+}
+
+func (ir *run) importItem(it *item) error {
+	if it.PostType != "post" && it.PostType != "page" {
+		return nil
+	}
+
+	status, ok := mapStatus(it.Status)
+	if !ok {
+		ir.stats.Skipped[kindPost]++
+		return nil
+	}
+
+	authorID, ok := ir.users[it.Creator]
+	if !ok {
+		ir.warn("%s %s: unknown author %q, skipped", it.PostType, it.PostID, it.Creator)
+		ir.stats.Skipped[kindPost]++
+		return nil
+	}
+
+	content, err := toMarkdown(ir.converter, it.content())
+	if err != nil {
+		ir.warn("%s %s: could not convert content to Markdown, kept as HTML", it.PostType, it.PostID)
+		content = it.content()
+	}
+
+	title := strings.TrimSpace(it.Title)
+	if title == "" {
+		title = "(untitled)"
+	}
+
+	fields := models.Post{
+		Title:         title,
+		Content:       content,
+		Excerpt:       strings.TrimSpace(it.excerpt()),
+		FeaturedImage: ir.featuredImage(it),
+		Status:        status,
+		Locale:        ir.opts.Locale,
+		Visibility:    models.PostVisibilityPublic,
+		AuthorID:      authorID,
+	}
+	switch {
+	case it.Status == "private":
+		fields.Visibility = models.PostVisibilityPrivate
+	case it.PostPassword != "":
+		fields.Visibility = models.PostVisibilityPassword
+		if err := fields.SetPassword(it.PostPassword); err != nil {
+			return err
+		}
+	}
+	switch date := it.date(); status {
+	case models.PostStatusPublished:
+		fields.PublishedAt = &date
+	case models.PostStatusScheduled:
+		// Posts scheduled in WordPress go live here at the same time.
+		fields.ScheduledAt = &date
+	}
+
+	primary, secondaries, tagIDs, err := ir.itemTerms(it)
+	if err != nil {
+		return err
+	}
+
+	post, err := ir.upsertPost(it, fields, primary, secondaries, tagIDs)
+	if err != nil {
+		return err
+	}
+
+	if err := ir.recordLegacyURLs(it, post.ID); err != nil {
+		return err
+	}
+	return ir.importComments(it, post.ID)
+}
+
+func (ir *run) upsertPost(it *item, fields models.Post, primary *uuid.UUID, secondaries, tagIDs []uuid.UUID) (*models.Post, error) {
+	var post models.Post
+	if id, ok := ir.lookup(kindPost, it.PostID); ok {
+		if err := ir.tx.Unscoped().First(&post, "id = ?", id).Error; err == nil {
+			if !ir.opts.Update {
+				ir.stats.Skipped[kindPost]++
+				return &post, nil
+			}
+
+			if err := models.MoveOwnership(ir.tx, &post, fields.AuthorID); err != nil {
+				return nil, err
+			}
+			if fields.Content != post.Content {
+				if err := annotations.Reanchor(ir.tx, post.ID, fields.Content, post.Version+1); err != nil {
+					return nil, err
+				}
+			}
+			if err := ir.tx.Model(&post).UpdateColumns(map[string]interface{}{
+				"title":             fields.Title,
+				"content":           fields.Content,
+				"content_html":      "",
+				"content_hash":      "",
+				"excerpt":           fields.Excerpt,
+				"excerpt_generated": false,
+				"featured_image":    fields.FeaturedImage,
+				"status":            fields.Status,
+				"visibility":        fields.Visibility,
+				"password_hash":     fields.PasswordHash,
+				"author_id":         fields.AuthorID,
+				"published_at":      fields.PublishedAt,
+				"scheduled_at":      fields.ScheduledAt,
+				"category_id":       primary,
+				"updated_at":        it.modified(),
+				"version":           gorm.Expr("version + 1"),
+			}).Error; err != nil {
+				return nil, err
+			}
+			if err := ir.setTaxonomy(&post, secondaries, tagIDs); err != nil {
+				return nil, err
+			}
+			ir.written = append(ir.written, models.Post{ID: post.ID, Slug: post.Slug, Locale: post.Locale, Content: fields.Content})
+			ir.stats.Updated[kindPost]++
+			return &post, nil
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		// The post was purged since the last import; create it again.
+	}
+
+	slug, err := ir.uniqueSlug(it)
+	if err != nil {
+		return nil, err
+	}
+
+	post = fields
+	post.Slug = slug
+	post.SlugPinned = true
+	post.CategoryID = primary
+	post.CreatedAt = it.date()
+	post.UpdatedAt = it.modified()
+
+	// Rendered HTML is left empty and filled in on first read.
+	if err := ir.tx.Create(&post).Error; err != nil {
+		return nil, err
+	}
+
+	acceptedAt := post.CreatedAt
+	if err := ir.tx.Create(&models.PostCollaborator{
+		PostID:     post.ID,
+		UserID:     post.AuthorID,
+		Role:       models.CollaboratorRoleOwner,
+		Status:     models.CollaboratorStatusAccepted,
+		AcceptedAt: &acceptedAt,
+	}).Error; err != nil {
+		return nil, err
+	}
+
+	if err := ir.setTaxonomy(&post, secondaries, tagIDs); err != nil {
+		return nil, err
+	}
+
+	ir.written = append(ir.written, post)
+	ir.stats.Created[kindPost]++
+	return &post, ir.record(kindPost, it.PostID, post.ID)
+}
+
+// indexPosts adds the links in the written posts to the link graph and
+// fingerprints their content. Links to the old site resolve through the
+// legacy URLs recorded for each item, so this runs after all of them have
+// been imported.
+func (ir *run) indexPosts(graph *linkgraph.Graph) error {
+	if u, err := url.Parse(ir.opts.Source); err == nil && u.Hostname() != "" {
+		graph = graph.WithHost(u.Hostname())
+	}
+	for i := range ir.written {
+		if err := linkgraph.Attach(ir.tx, &ir.written[i]); err != nil {
+			return err
+		}
+	}
+	for i := range ir.written {
+		if err := graph.Update(ir.tx, &ir.written[i]); err != nil {
+			return err
+		}
+		if _, err := ir.duplicates.Check(ir.tx, duplicates.KindPost, ir.written[i].ID, ir.written[i].Content); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ir *run) setTaxonomy(post *models.Post, secondaries, tagIDs []uuid.UUID) error {
+	if err := ir.replaceAssociation(post, "SecondaryCategories", &[]models.Category{}, secondaries); err != nil {
+		return err
+	}
+	return ir.replaceAssociation(post, "Tags", &[]models.Tag{}, tagIDs)
+}
+
+func (ir *run) replaceAssociation(post *models.Post, name string, rows interface{}, ids []uuid.UUID) error {
+	association := ir.tx.Model(post).Association(name)
+	if len(ids) == 0 {
+		return association.Clear()
+	}
+	if err := ir.tx.Where("id IN ?", ids).Find(rows).Error; err != nil {
+		return err
+	}
+	return association.Replace(rows)
+}
+
+// itemTerms maps an item's categories and tags. The first category becomes
+// the primary category and the rest secondary ones.
+func (ir *run) itemTerms(it *item) (*uuid.UUID, []uuid.UUID, []uuid.UUID, error) {
+	var primary *uuid.UUID
+	var secondaries, tagIDs []uuid.UUID
+
+	for _, term := range it.Terms {
+		switch term.Domain {
+		case "category":
+			id, err := ir.categoryID(term.Nicename, term.Name, nil)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			if primary == nil {
+				primary = &id
+			} else if id != *primary {
+				secondaries = append(secondaries, id)
+			}
+		case "post_tag":
+			id, err := ir.tagID(term.Nicename, term.Name)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			tagIDs = append(tagIDs, id)
+		}
+	}
+	return primary, secondaries, tagIDs, nil
+}
+
+// uniqueSlug keeps the WordPress slug when it is free, so old permalinks map
+// cleanly, and otherwise appends the WordPress post ID.
+func (ir *run) uniqueSlug(it *item) (string, error) {
+	base := slugify.Make(decodeSlug(it.PostName))
+	if base == "" {
+		base = slugify.Make(it.Title)
+	}
+	if base == "" {
+		base = "post"
+	}
+
+	candidates := []string{base, base + "-" + it.PostID}
+	for _, slug := range candidates {
+		taken, err := ir.slugTaken(slug)
+		if err != nil {
+			return "", err
+		}
+		if !taken {
+			return slug, nil
+		}
+	}
+	return base + "-" + uuid.New().String()[:8], nil
+}
+
+func (ir *run) slugTaken(slug string) (bool, error) {
+	var count int64
+	if err := ir.tx.Unscoped().Model(&models.Post{}).Where("locale = ? AND slug = ?", ir.opts.Locale, slug).Count(&count).Error; err != nil {
+		return false, err
+	}
+	if count > 0 {
+		return true, nil
+	}
+	if err := ir.tx.Model(&models.PostSlug{}).Where("locale = ? AND slug = ?", ir.opts.Locale, slug).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (ir *run) featuredImage(it *item) string {
+	for _, meta := range it.Meta {
+		if meta.Key == "_thumbnail_id" {
+			return ir.attachments[meta.Value]
+		}
+	}
+	return ""
+}
+
+// recordLegacyURLs stores the paths WordPress served the item under: its
+// permalink and the ?p= / ?page_id= forms.
+func (ir *run) recordLegacyURLs(it *item, postID uuid.UUID) error {
+	paths := []string{models.LegacyPath(it.Link)}
+	if it.PostType == "page" {
+		paths = append(paths, "/?page_id="+it.PostID)
+	} else {
+		paths = append(paths, "/?p="+it.PostID)
+	}
+	if guid := models.LegacyPath(it.GUID); strings.Contains(guid, "?") {
+		paths = append(paths, guid)
+	}
+
+	for _, path := range paths {
+		if path == "" || path == "/" {
+			continue
+		}
+		legacy := models.LegacyURL{Path: path, PostID: postID}
+		if err := ir.tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&legacy).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// importComments adds approved and pending comments that were not imported
+// before, keeping their threading. Hooks are skipped so imported comments do
+// not show up as new engagement; the post's comment count is recomputed
+// instead.
+func (ir *run) importComments(it *item, postID uuid.UUID) error {
+	comments := make([]comment, 0, len(it.Comments))
+	for _, c := range it.Comments {
+		if c.Type == "pingback" || c.Type == "trackback" {
+			continue
+		}
+		if c.Approved != "1" && c.Approved != "0" {
+			continue
+		}
+		comments = append(comments, c)
+	}
+	if len(comments) == 0 {
+		return nil
+	}
+
+	// WordPress comment IDs increase over time, so parents sort first.
+	sort.Slice(comments, func(i, j int) bool {
+		a, _ := strconv.Atoi(comments[i].ID)
+		b, _ := strconv.Atoi(comments[j].ID)
+		return a < b
+	})
+
+	created := 0
+	for _, c := range comments {
+		if _, ok := ir.lookup(kindComment, c.ID); ok {
+			ir.stats.Skipped[kindComment]++
+			continue
+		}
+
+		userID, err := ir.commenterID(c)
+		if err != nil {
+			return err
+		}
+
+		content, err := toMarkdown(ir.converter, c.Content)
+		if err != nil {
+			content = c.Content
+		}
+
+		date := c.date()
+		row := models.Comment{
+			PostID:     postID,
+			UserID:     userID,
+			Content:    content,
+			IsApproved: c.Approved == "1",
+			Held:       c.Approved != "1",
+			CreatedAt:  date,
+			UpdatedAt:  date,
+		}
+		if c.Parent != "" && c.Parent != "0" {
+			if parentID, ok := ir.lookup(kindComment, c.Parent); ok {
+				row.ParentID = &parentID
+			}
+		}
+
+		if err := ir.tx.Session(&gorm.Session{SkipHooks: true}).Create(&row).Error; err != nil {
+			return err
+		}
+		if _, err := ir.duplicates.Check(ir.tx, duplicates.KindComment, row.ID, row.Content); err != nil {
+			return err
+		}
+		if err := ir.record(kindComment, c.ID, row.ID); err != nil {
+			return err
+		}
+		ir.stats.Created[kindComment]++
+		created++
+	}
+
+	if created == 0 {
+		return nil
+	}
+	return ir.tx.Model(&models.Post{}).Where("id = ?", postID).
+		UpdateColumn("comment_count", ir.tx.Model(&models.Comment{}).Select("COUNT(*)").Where("post_id = ? AND held = ?", postID, false)).Error
+}
+
+func (ir *run) lookup(kind, externalID string) (uuid.UUID, bool) {
+	var record models.ImportRecord
+	if err := ir.tx.Where("source = ? AND kind = ? AND external_id = ?", ir.opts.Source, kind, externalID).
+		First(&record).Error; err != nil {
+		return uuid.Nil, false
+	}
+	return record.LocalID, true
+}
+
+func (ir *run) record(kind, externalID string, localID uuid.UUID) error {
+	return ir.tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "source"}, {Name: "kind"}, {Name: "external_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"local_id", "updated_at"}),
+	}).Create(&models.ImportRecord{
+		Source:     ir.opts.Source,
+		Kind:       kind,
+		ExternalID: externalID,
+		LocalID:    localID,
+	}).Error
+}
+
+func (ir *run) uniqueUsername(base string) string {
+	base = strings.TrimSpace(base)
+	username := base
+	for i := 2; ; i++ {
+		var count int64
+		ir.tx.Unscoped().Model(&models.User{}).Where("username = ?", username).Count(&count)
+		if count == 0 {
+			return username
+		}
+		username = fmt.Sprintf("%s-%d", base, i)
+	}
+}
+
+func (ir *run) warn(format string, args ...interface{}) {
+	ir.stats.Warnings = append(ir.stats.Warnings, fmt.Sprintf(format, args...))
+}
+
+func mapStatus(status string) (models.PostStatus, bool) {
+	switch status {
+	case "publish":
+		return models.PostStatusPublished, true
+	case "future":
+		return models.PostStatusScheduled, true
+	case "private":
+		// Private is a visibility here rather than a status.
+		return models.PostStatusPublished, true
+	case "draft", "pending":
+		return models.PostStatusDraft, true
+	}
+	return "", false
+}
+
+// placeholderEmail returns email, or a non-deliverable address for accounts
+// WordPress exported without one.
+func placeholderEmail(email, fallback string) string {
+	if email = strings.TrimSpace(email); email != "" {
+		return email
+	}
+	return slugify.Make(fallback) + "@wordpress.invalid"
+}
+
+// decodeSlug undoes the percent-encoding WordPress applies to non-ASCII
+// slugs.
+func decodeSlug(slug string) string {
+	if decoded, err := url.PathUnescape(slug); err == nil {
+		return decoded
+	}
+	return slug
+}
+
+func shortHash(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:4])
+}
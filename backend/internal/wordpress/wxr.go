@@ -0,0 +1,179 @@
+// Package wordpress imports WordPress WXR export files.
+package wordpress
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+	"time"
+)
+
+// Fields are matched by local name only, because the wp: namespace URI
+// changes between WXR versions.
+
+type wxr struct {
+	Channel channel `xml:"channel"`
+}
+
+type channel struct {
+	Links       []string   `xml:"link"`
+	BaseSiteURL string     `xml:"base_site_url"`
+	Authors     []author   `xml:"author"`
+	Categories  []category `xml:"category"`
+	Tags        []tag      `xml:"tag"`
+	Items       []item     `xml:"item"`
+}
+
+type author struct {
+	ID          string `xml:"author_id"`
+	Login       string `xml:"author_login"`
+	Email       string `xml:"author_email"`
+	DisplayName string `xml:"author_display_name"`
+	FirstName   string `xml:"author_first_name"`
+	LastName    string `xml:"author_last_name"`
+}
+
+type category struct {
+	TermID      string `xml:"term_id"`
+	Nicename    string `xml:"category_nicename"`
+	Parent      string `xml:"category_parent"`
+	Name        string `xml:"cat_name"`
+	Description string `xml:"category_description"`
+}
+
+type tag struct {
+	TermID string `xml:"term_id"`
+	Slug   string `xml:"tag_slug"`
+	Name   string `xml:"tag_name"`
+}
+
+type item struct {
+	Title         string     `xml:"title"`
+	Link          string     `xml:"link"`
+	GUID          string     `xml:"guid"`
+	Creator       string     `xml:"creator"`
+	Encoded       []encoded  `xml:"encoded"`
+	PostID        string     `xml:"post_id"`
+	PostDate      string     `xml:"post_date"`
+	PostDateGMT   string     `xml:"post_date_gmt"`
+	ModifiedGMT   string     `xml:"post_modified_gmt"`
+	PostName      string     `xml:"post_name"`
+	Status        string     `xml:"status"`
+	PostType      string     `xml:"post_type"`
+	PostPassword  string     `xml:"post_password"`
+	PostParent    string     `xml:"post_parent"`
+	AttachmentURL string     `xml:"attachment_url"`
+	Terms         []itemTerm `xml:"category"`
+	Comments      []comment  `xml:"comment"`
+	Meta          []postMeta `xml:"postmeta"`
+}
+
+// encoded holds either content:encoded or excerpt:encoded; they share a
+// local name and differ only by namespace.
+type encoded struct {
+	XMLName xml.Name
+	Value   string `xml:",chardata"`
+}
+
+type itemTerm struct {
+	Domain   string `xml:"domain,attr"`
+	Nicename string `xml:"nicename,attr"`
+	Name     string `xml:",chardata"`
+}
+
+type postMeta struct {
+	Key   string `xml:"meta_key"`
+	Value string `xml:"meta_value"`
+}
+
+type comment struct {
+	ID          string `xml:"comment_id"`
+	Author      string `xml:"comment_author"`
+	AuthorEmail string `xml:"comment_author_email"`
+	AuthorURL   string `xml:"comment_author_url"`
+	DateGMT     string `xml:"comment_date_gmt"`
+	Date        string `xml:"comment_date"`
+	Content     string `xml:"comment_content"`
+	Approved    string `xml:"comment_approved"`
+	Type        string `xml:"comment_type"`
+	Parent      string `xml:"comment_parent"`
+	UserID      string `xml:"comment_user_id"`
+}
+
+func parse(r io.Reader) (*channel, error) {
+	var doc wxr
+	decoder := xml.NewDecoder(r)
+	decoder.Strict = false
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, err
+	}
+	return &doc.Channel, nil
+}
+
+// siteURL returns the site the export came from. atom:link shares the local
+// name of the channel link, so empty matches are skipped.
+func (ch *channel) siteURL() string {
+	if ch.BaseSiteURL != "" {
+		return ch.BaseSiteURL
+	}
+	for _, link := range ch.Links {
+		if link = strings.TrimSpace(link); link != "" {
+			return link
+		}
+	}
+	return ""
+}
+
+func (it *item) content() string {
+	return it.encodedValue("/content/")
+}
+
+func (it *item) excerpt() string {
+	return it.encodedValue("/excerpt/")
+}
+
+func (it *item) encodedValue(namespace string) string {
+	for _, e := range it.Encoded {
+		if strings.Contains(e.XMLName.Space, namespace) {
+			return e.Value
+		}
+	}
+	return ""
+}
+
+// date returns the GMT timestamp, falling back to the local one for drafts,
+// which WordPress exports with a zero GMT date.
+func (it *item) date() time.Time {
+	if t, ok := parseDate(it.PostDateGMT); ok {
+		return t
+	}
+	t, _ := parseDate(it.PostDate)
+	return t
+}
+
+func (it *item) modified() time.Time {
+	if t, ok := parseDate(it.ModifiedGMT); ok {
+		return t
+	}
+	return it.date()
+}
+
+func (c *comment) date() time.Time {
+	if t, ok := parseDate(c.DateGMT); ok {
+		return t
+	}
+	t, _ := parseDate(c.Date)
+	return t
+}
+
+func parseDate(value string) (time.Time, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" || strings.HasPrefix(value, "0000-00-00") {
+		return time.Time{}, false
+	}
+	t, err := time.Parse("2006-01-02 15:04:05", value)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t.UTC(), true
+}
@@ -0,0 +1,60 @@
+package auth
+
+import (
+	"errors"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+type PreviewClaims struct {
+	PostID uuid.UUID `json:"post_id"`
+	Type   string    `json:"type"`
+	jwt.RegisteredClaims
+}
+
+// GeneratePreviewToken signs a read-only preview token for a post. The token
+// ID is the preview link ID so the link can be revoked server-side.
+func (m *JWTManager) GeneratePreviewToken(linkID, postID uuid.UUID, expiresAt time.Time) (string, error) {
+	now := time.Now()
+	claims := PreviewClaims{
+		PostID: postID,
+		Type:   "preview",
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        linkID.String(),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			NotBefore: jwt.NewNumericDate(now),
+			Issuer:    "modernblog-api",
+			Subject:   postID.String(),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(m.secretKey))
+}
+
+func (m *JWTManager) ValidatePreviewToken(tokenString string) (*PreviewClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &PreviewClaims{}, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, errors.New("unexpected signing method")
+		}
+		return []byte(m.secretKey), nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	claims, ok := token.Claims.(*PreviewClaims)
+	if !ok || !token.Valid {
+		return nil, errors.New("invalid token")
+	}
+
+	if claims.Type != "preview" {
+		return nil, errors.New("invalid token type")
+	}
+
+	return claims, nil
+}
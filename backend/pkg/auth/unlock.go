@@ -0,0 +1,62 @@
+package auth
+
+import (
+	"errors"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+type UnlockClaims struct {
+	PostID uuid.UUID `json:"post_id"`
+	Stamp  string    `json:"stamp"`
+	Type   string    `json:"type"`
+	jwt.RegisteredClaims
+}
+
+// GenerateUnlockToken signs a token that grants read access to one
+// password-protected post. The stamp ties it to the password it was issued
+// for.
+func (m *JWTManager) GenerateUnlockToken(postID uuid.UUID, stamp string, expiresAt time.Time) (string, error) {
+	now := time.Now()
+	claims := UnlockClaims{
+		PostID: postID,
+		Stamp:  stamp,
+		Type:   "unlock",
+		RegisteredClaims: jwt.RegisteredClaims{
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			NotBefore: jwt.NewNumericDate(now),
+			Issuer:    "modernblog-api",
+			Subject:   postID.String(),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(m.secretKey))
+}
+
+func (m *JWTManager) ValidateUnlockToken(tokenString string) (*UnlockClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &UnlockClaims{}, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, errors.New("unexpected signing method")
+		}
+		return []byte(m.secretKey), nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	claims, ok := token.Claims.(*UnlockClaims)
+	if !ok || !token.Valid {
+		return nil, errors.New("invalid token")
+	}
+
+	if claims.Type != "unlock" {
+		return nil, errors.New("invalid token type")
+	}
+
+	return claims, nil
+}
@@ -0,0 +1,68 @@
+// Package frontmatter reads and writes Markdown documents that start with a
+// YAML front matter block delimited by "---" lines.
+package frontmatter
+
+import (
+	"bytes"
+	"errors"
+
+	"gopkg.in/yaml.v3"
+)
+
+const delimiter = "---"
+
+var ErrMissing = errors.New("document has no front matter")
+
+// Parse decodes the front matter of src into v and returns the body that
+// follows it, byte for byte.
+func Parse(src []byte, v interface{}) (string, error) {
+	src = bytes.TrimPrefix(src, []byte("\ufeff"))
+
+	first, rest, ok := cutLine(src)
+	if !ok || string(first) != delimiter {
+		return "", ErrMissing
+	}
+
+	var front []byte
+	for {
+		line, remaining, more := cutLine(rest)
+		if string(line) == delimiter {
+			if err := yaml.Unmarshal(front, v); err != nil {
+				return "", err
+			}
+			return string(remaining), nil
+		}
+		if !more {
+			return "", errors.New("front matter is not closed")
+		}
+		front = append(front, line...)
+		front = append(front, '\n')
+		rest = remaining
+	}
+}
+
+// Format encodes v as front matter followed by body. Parse returns body
+// unchanged for any document Format produces.
+func Format(v interface{}, body string) ([]byte, error) {
+	front, err := yaml.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(delimiter + "\n")
+	buf.Write(front)
+	buf.WriteString(delimiter + "\n")
+	buf.WriteString(body)
+	return buf.Bytes(), nil
+}
+
+// cutLine splits off the first line of b, without its line ending. more is
+// false when b held no line ending.
+func cutLine(b []byte) (line, rest []byte, more bool) {
+	i := bytes.IndexByte(b, '\n')
+	if i < 0 {
+		return bytes.TrimSuffix(b, []byte("\r")), nil, false
+	}
+	return bytes.TrimSuffix(b[:i], []byte("\r")), b[i+1:], true
+}
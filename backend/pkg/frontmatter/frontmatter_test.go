@@ -0,0 +1,133 @@
+package frontmatter
+
+import (
+	"reflect"
+	"testing"
+)
+
+type meta struct {
+	Title string   `yaml:"title"`
+	Tags  []string `yaml:"tags,omitempty"`
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		src      string
+		want     meta
+		wantBody string
+		wantErr  bool
+	}{
+		{
+			name:     "simple",
+			src:      "---\ntitle: Hello\ntags: [a, b]\n---\nBody text.\n",
+			want:     meta{Title: "Hello", Tags: []string{"a", "b"}},
+			wantBody: "Body text.\n",
+		},
+		{
+			name:     "CRLF line endings",
+			src:      "---\r\ntitle: Hello\r\ntags:\r\n  - a\r\n---\r\nLine one.\r\nLine two.\r\n",
+			want:     meta{Title: "Hello", Tags: []string{"a"}},
+			wantBody: "Line one.\r\nLine two.\r\n",
+		},
+		{
+			name:     "byte order mark",
+			src:      "\ufeff---\ntitle: Hello\n---\nBody",
+			want:     meta{Title: "Hello"},
+			wantBody: "Body",
+		},
+		{
+			name:     "body with a thematic break",
+			src:      "---\ntitle: Hello\n---\nAbove\n\n---\n\nBelow\n",
+			want:     meta{Title: "Hello"},
+			wantBody: "Above\n\n---\n\nBelow\n",
+		},
+		{
+			name:     "empty body",
+			src:      "---\ntitle: Hello\n---",
+			want:     meta{Title: "Hello"},
+			wantBody: "",
+		},
+		{
+			name:    "missing front matter",
+			src:     "# Just Markdown\n",
+			wantErr: true,
+		},
+		{
+			name:    "unclosed front matter",
+			src:     "---\ntitle: Hello\nBody\n",
+			wantErr: true,
+		},
+		{
+			name:    "invalid YAML",
+			src:     "---\ntitle: [unclosed\n---\nBody\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got meta
+			body, err := Parse([]byte(tt.src), &got)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("Parse succeeded, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("front matter = %+v, want %+v", got, tt.want)
+			}
+			if body != tt.wantBody {
+				t.Errorf("body = %q, want %q", body, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestParseMissing(t *testing.T) {
+	var m meta
+	if _, err := Parse([]byte("no front matter"), &m); err != ErrMissing {
+		t.Errorf("error = %v, want ErrMissing", err)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		meta meta
+		body string
+	}{
+		{"plain", meta{Title: "Hello", Tags: []string{"go", "web"}}, "Some *Markdown*.\n"},
+		{"no trailing newline", meta{Title: "Hello"}, "Last line"},
+		{"empty body", meta{Title: "Hello"}, ""},
+		{"CRLF body", meta{Title: "Hello"}, "One\r\nTwo\r\n"},
+		{"body starting with a delimiter", meta{Title: "Hello"}, "---\nnot front matter\n---\n"},
+		{"title that needs quoting", meta{Title: "Colon: \"quoted\" --- and more"}, "Body\n"},
+		{"unicode", meta{Title: "שלום עולם", Tags: []string{"עברית"}}, "גוף הטקסט 🎉\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := Format(tt.meta, tt.body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got meta
+			body, err := Parse(doc, &got)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", doc, err)
+			}
+			if !reflect.DeepEqual(got, tt.meta) {
+				t.Errorf("front matter = %+v, want %+v", got, tt.meta)
+			}
+			if body != tt.body {
+				t.Errorf("body = %q, want %q", body, tt.body)
+			}
+		})
+	}
+}
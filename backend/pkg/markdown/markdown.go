@@ -0,0 +1,281 @@
+package markdown
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	chromahtml "github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	highlighting "github.com/yuin/goldmark-highlighting/v2"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	extast "github.com/yuin/goldmark/extension/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+)
+
+const (
+	// WordsPerMinute is the reading speed used to estimate reading time.
+	WordsPerMinute = 200
+	// ExcerptLength is the maximum length, in characters, of a generated
+	// excerpt.
+	ExcerptLength = 200
+)
+
+type Heading struct {
+	Level int    `json:"level"`
+	ID    string `json:"id"`
+	Text  string `json:"text"`
+}
+
+type Result struct {
+	HTML        string
+	TOC         []Heading
+	WordCount   int
+	ReadingTime int
+	// Excerpt is the plain text of the opening paragraphs, shortened to
+	// ExcerptLength.
+	Excerpt string
+}
+
+type Renderer struct {
+	md     goldmark.Markdown
+	policy *bluemonday.Policy
+}
+
+func NewRenderer() *Renderer {
+	md := goldmark.New(
+		goldmark.WithExtensions(
+			extension.Linkify,
+			extension.Strikethrough,
+			extension.TaskList,
+			extension.NewTable(
+				extension.WithTableCellAlignMethod(extension.TableCellAlignAttribute),
+			),
+			extension.Footnote,
+			highlighting.NewHighlighting(
+				highlighting.WithFormatOptions(chromahtml.WithClasses(true)),
+			),
+		),
+		goldmark.WithParserOptions(
+			parser.WithAutoHeadingID(),
+		),
+	)
+
+	return &Renderer{
+		md:     md,
+		policy: newPolicy(),
+	}
+}
+
+// Render converts Markdown source into sanitized HTML along with the
+// metadata derived from the document structure.
+func (r *Renderer) Render(source string) (*Result, error) {
+	src := []byte(source)
+	doc := r.md.Parser().Parse(text.NewReader(src))
+
+	var buf bytes.Buffer
+	if err := r.md.Renderer().Render(&buf, src, doc); err != nil {
+		return nil, err
+	}
+
+	words := countWords(doc, src)
+
+	return &Result{
+		HTML:        r.policy.Sanitize(buf.String()),
+		TOC:         collectHeadings(doc, src),
+		WordCount:   words,
+		ReadingTime: ReadingTime(words),
+		Excerpt:     excerpt(doc, src),
+	}, nil
+}
+
+// Links returns the destinations of the links in source, in document order,
+// including bare URLs picked up by Linkify.
+func (r *Renderer) Links(source string) []string {
+	src := []byte(source)
+	doc := r.md.Parser().Parse(text.NewReader(src))
+
+	links := []string{}
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch t := n.(type) {
+		case *ast.Link:
+			links = append(links, string(t.Destination))
+		case *ast.AutoLink:
+			if t.AutoLinkType == ast.AutoLinkURL {
+				links = append(links, string(t.URL(src)))
+			}
+		}
+		return ast.WalkContinue, nil
+	})
+	return links
+}
+
+// ReadingTime returns the estimated reading time in minutes, rounded up.
+func ReadingTime(words int) int {
+	if words == 0 {
+		return 0
+	}
+	return int(math.Ceil(float64(words) / WordsPerMinute))
+}
+
+// Truncate shortens text to at most limit characters, cutting at a word
+// boundary where possible and marking the cut with an ellipsis.
+func Truncate(text string, limit int) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if limit < 1 {
+		return ""
+	}
+	if utf8.RuneCountInString(text) <= limit {
+		return text
+	}
+	runes := []rune(text)
+	cut := string(runes[:limit-1])
+	if i := strings.LastIndexByte(cut, ' '); i > len(cut)/2 {
+		cut = cut[:i]
+	}
+	return strings.TrimRightFunc(cut, func(r rune) bool {
+		return r == ' ' || strings.ContainsRune(",;:.-", r)
+	}) + "…"
+}
+
+// Hash returns a stable fingerprint of the source, used to detect whether a
+// previously rendered result is stale.
+func Hash(source string) string {
+	sum := sha256.Sum256([]byte(source))
+	return hex.EncodeToString(sum[:])
+}
+
+func newPolicy() *bluemonday.Policy {
+	p := bluemonday.UGCPolicy()
+
+	// Heading anchors and footnote back-references.
+	p.AllowAttrs("id").Matching(regexp.MustCompile(`^[a-zA-Z0-9_:\-]+$`)).
+		OnElements("h1", "h2", "h3", "h4", "h5", "h6", "li", "sup")
+
+	// Syntax highlighting emits chroma token classes.
+	p.AllowAttrs("class").Matching(regexp.MustCompile(`^[a-zA-Z0-9_\- ]+$`)).
+		OnElements("pre", "code", "span", "div", "a", "sup", "hr")
+
+	p.AllowAttrs("role").Matching(regexp.MustCompile(`^doc-[a-z]+$`)).
+		OnElements("a", "div", "section", "hr")
+
+	p.AllowAttrs("align").Matching(regexp.MustCompile(`^(left|center|right)$`)).
+		OnElements("th", "td")
+
+	p.AllowElements("section")
+	p.AllowAttrs("type", "checked", "disabled").OnElements("input")
+
+	return p
+}
+
+func collectHeadings(doc ast.Node, src []byte) []Heading {
+	headings := []Heading{}
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		heading, ok := n.(*ast.Heading)
+		if !ok {
+			return ast.WalkContinue, nil
+		}
+
+		var id string
+		if v, ok := heading.AttributeString("id"); ok {
+			if b, ok := v.([]byte); ok {
+				id = string(b)
+			}
+		}
+
+		headings = append(headings, Heading{
+			Level: heading.Level,
+			ID:    id,
+			Text:  plainText(heading, src),
+		})
+		return ast.WalkSkipChildren, nil
+	})
+	return headings
+}
+
+func plainText(n ast.Node, src []byte) string {
+	var sb strings.Builder
+	_ = ast.Walk(n, func(child ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch t := child.(type) {
+		case *ast.Image:
+			return ast.WalkSkipChildren, nil
+		case *ast.Text:
+			sb.Write(t.Segment.Value(src))
+			if t.SoftLineBreak() || t.HardLineBreak() {
+				sb.WriteByte(' ')
+			}
+		case *ast.String:
+			sb.Write(t.Value)
+		}
+		return ast.WalkContinue, nil
+	})
+	return strings.TrimSpace(sb.String())
+}
+
+// excerpt joins the text of the document's paragraphs until there is enough
+// for an excerpt. Headings, code, tables and footnotes are left out.
+func excerpt(doc ast.Node, src []byte) string {
+	var parts []string
+	length := 0
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		if length > ExcerptLength {
+			return ast.WalkStop, nil
+		}
+		switch n.(type) {
+		case *ast.Heading, *ast.FencedCodeBlock, *ast.CodeBlock, *ast.HTMLBlock,
+			*extast.Table, *extast.FootnoteList:
+			return ast.WalkSkipChildren, nil
+		case *ast.Paragraph, *ast.TextBlock:
+			if text := plainText(n, src); text != "" {
+				parts = append(parts, text)
+				length += len(text) + 1
+			}
+			return ast.WalkSkipChildren, nil
+		}
+		return ast.WalkContinue, nil
+	})
+	return Truncate(strings.Join(parts, " "), ExcerptLength)
+}
+
+func countWords(doc ast.Node, src []byte) int {
+	count := 0
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch t := n.(type) {
+		case *ast.Text:
+			count += len(strings.Fields(string(t.Segment.Value(src))))
+		case *ast.String:
+			count += len(strings.Fields(string(t.Value)))
+		case *ast.FencedCodeBlock, *ast.CodeBlock:
+			lines := n.Lines()
+			for i := 0; i < lines.Len(); i++ {
+				line := lines.At(i)
+				count += len(strings.Fields(string(line.Value(src))))
+			}
+			return ast.WalkSkipChildren, nil
+		}
+		return ast.WalkContinue, nil
+	})
+	return count
+}
@@ -0,0 +1,25 @@
+// Package slugify turns titles and names into URL slugs.
+package slugify
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Make lowercases s, turns spaces and underscores into hyphens and drops
+// every other character that is not a letter, a digit or a hyphen. Letters
+// outside ASCII, such as Hebrew, are kept and percent-encoded in URLs.
+func Make(s string) string {
+	slug := strings.ToLower(s)
+	slug = strings.ReplaceAll(slug, " ", "-")
+	slug = strings.ReplaceAll(slug, "_", "-")
+
+	var result strings.Builder
+	for _, char := range slug {
+		if char == '-' || unicode.IsLetter(char) || unicode.IsNumber(char) {
+			result.WriteRune(char)
+		}
+	}
+
+	return strings.Trim(result.String(), "-")
+}
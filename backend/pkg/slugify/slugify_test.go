@@ -0,0 +1,26 @@
+package slugify
+
+import "testing"
+
+func TestMake(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"Hello World", "hello-world"},
+		{"Go 1.23: What's New?", "go-123-whats-new"},
+		{"snake_case_title", "snake-case-title"},
+		{"  -Trimmed- ", "trimmed"},
+		{"שלום עולם", "שלום-עולם"},
+		{"מדריך Go 2024", "מדריך-go-2024"},
+		{"Café Ünïcode", "café-ünïcode"},
+		{"!!!", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := Make(tt.in); got != tt.want {
+			t.Errorf("Make(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}